@@ -1,22 +1,51 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/xanzy/go-gitlab"
 
 	"gitlab-code-review-notifier/internal"
 	"gitlab-code-review-notifier/internal/controller"
 	"gitlab-code-review-notifier/internal/database"
+	"gitlab-code-review-notifier/pkg/config"
 	"gitlab-code-review-notifier/pkg/envutil"
 	"gitlab-code-review-notifier/pkg/firingservice"
+	"gitlab-code-review-notifier/pkg/gitlabhook"
 	"gitlab-code-review-notifier/pkg/gitlabservice"
+	"gitlab-code-review-notifier/pkg/interaction"
 	"gitlab-code-review-notifier/pkg/log"
 	"gitlab-code-review-notifier/pkg/notifier"
+	"gitlab-code-review-notifier/pkg/outbox"
 	"gitlab-code-review-notifier/pkg/scheduler"
+	"gitlab-code-review-notifier/pkg/webhook"
+	"gitlab-code-review-notifier/pkg/workerpool"
+)
+
+// notificationLogRetention is how long notifications are remembered to not repeat them,
+// it is extended to the longest realert interval of clients so that none of them realerts early
+const notificationLogRetention = 30 * 24 * time.Hour
+
+const (
+	outboxBaseRetryDelay = 30 * time.Second
+	outboxMaxRetryDelay  = time.Hour
+)
+
+// gitlabResponseRetention is how long unchanged responses of gitlab are kept to revalidate them
+const gitlabResponseRetention = 7 * 24 * time.Hour
+
+// Storages of cached gitlab responses
+const (
+	gitlabCacheStorageMemory   = "memory"
+	gitlabCacheStoragePostgres = "postgres"
+	gitlabCacheStorageNone     = "none"
 )
 
 func main() {
@@ -71,11 +100,151 @@ func main() {
 	sched := scheduler.NewScheduler(schedulerConf)
 
 	clientRepository := database.NewClientRepository(db)
+	targetRepository := database.NewTargetRepository(db)
+	userMappingRepository := database.NewUserMappingRepository(db)
+	escalationStepRepository := database.NewEscalationStepRepository(db)
+	notificationLogRepository := database.NewNotificationLogRepository(db)
+	notificationPostRepository := database.NewNotificationPostRepository(db)
+	notificationThreadRepository := database.NewNotificationThreadRepository(db)
+	outboxRepository := database.NewOutboxRepository(db)
+	acknowledgementRepository := database.NewAcknowledgementRepository(db)
+	clientRunRepository := database.NewClientRunRepository(db)
 	gitlabUrl := envutil.MustGetEnvStr(internal.EnvGitlabUrl)
-	gitlabClientFactory := gitlabservice.NewInstancedClientFactory(gitlabUrl)
+	var gitlabResponseCache *gitlabservice.ResponseCache
+	gitlabCacheMemoryBytes := int64(envutil.GetEnvUintOrDefault(internal.EnvGitlabCacheMemoryMegabytes, 64)) << 20
+	switch gitlabCacheStorage := envutil.GetEnvStrOrDefault(internal.EnvGitlabCacheStorage, gitlabCacheStorageMemory); gitlabCacheStorage {
+	case gitlabCacheStorageMemory:
+		gitlabResponseCache = gitlabservice.NewResponseCache(nil, gitlabCacheMemoryBytes)
+	case gitlabCacheStoragePostgres:
+		gitlabResponseCache = gitlabservice.NewResponseCache(database.NewGitlabResponseRepository(db), gitlabCacheMemoryBytes)
+	case gitlabCacheStorageNone:
+	default:
+		panic(fmt.Errorf("unknown %s %s", internal.EnvGitlabCacheStorage, gitlabCacheStorage))
+	}
+	var discussionCache *gitlabservice.DiscussionCache
+	if discussionCacheMinutes := envutil.GetEnvUintOrDefault(internal.EnvGitlabDiscussionCacheMinutes, 60); discussionCacheMinutes > 0 {
+		discussionCache = gitlabservice.NewDiscussionCache(time.Duration(discussionCacheMinutes) * time.Minute)
+	}
+	gitlabClientFactory := gitlabservice.NewInstancedClientFactory(gitlabUrl, gitlabservice.ClientOptions{
+		Concurrency:     int(envutil.GetEnvUintOrDefault(internal.EnvGitlabConcurrency, 4)),
+		ResponseCache:   gitlabResponseCache,
+		DiscussionCache: discussionCache,
+		RateLimiter:     gitlabservice.NewRateLimiter(),
+	})
 	notifierFactory := notifier.NewFactory("pkg/notifier/templates")
-	configuredClientFactory := firingservice.NewConfiguredClientFactory(gitlabClientFactory, notifierFactory)
-	service := firingservice.NewFiringService()
+	emailConfig := webhook.EmailConfig{
+		Host:     envutil.GetEnvStr(internal.EnvSmtpHost),
+		Port:     int(envutil.GetEnvUintOrDefault(internal.EnvSmtpPort, 587)),
+		Username: envutil.GetEnvStr(internal.EnvSmtpUsername),
+		Password: envutil.GetEnvStr(internal.EnvSmtpPassword),
+		From:     envutil.GetEnvStr(internal.EnvSmtpFrom),
+		Security: envutil.GetEnvStrOrDefault(internal.EnvSmtpSecurity, webhook.EmailSecurityStartTLS),
+	}
+	webhookRegistry := webhook.NewDefaultRegistry(emailConfig)
+	webhookResolver := firingservice.NewWebhookResolver(clientRepository, targetRepository, webhookRegistry)
+	var buttons *interaction.Buttons
+	callbackUrl := envutil.GetEnvStr(internal.EnvCallbackUrl)
+	if len(callbackUrl) > 0 {
+		buttons = interaction.NewButtons(callbackUrl, envutil.MustGetEnvStr(internal.EnvCallbackSecret))
+	}
+	configuredClientFactory := firingservice.NewConfiguredClientFactory(
+		gitlabClientFactory,
+		notifierFactory,
+		webhookRegistry,
+		notificationPostRepository,
+		notificationThreadRepository,
+		outbox.NewOutbox(outboxRepository),
+		buttons,
+	)
+	service := firingservice.NewFiringService(notificationLogRepository, acknowledgementRepository)
+
+	clientConcurrency := int(envutil.GetEnvUintOrDefault(internal.EnvClientConcurrency, 2))
+	clientTimeout := time.Duration(envutil.GetEnvUintOrDefault(internal.EnvClientTimeoutMinutes, 10)) * time.Minute
+
+	// clientLocks keep checks of a client triggered by gitlab events from overlapping scheduled ones
+	var clientLocksMu sync.Mutex
+	clientLocks := make(map[int]*sync.Mutex)
+	lockClient := func(clientId int) func() {
+		clientLocksMu.Lock()
+		lock, ok := clientLocks[clientId]
+		if !ok {
+			lock = &sync.Mutex{}
+			clientLocks[clientId] = lock
+		}
+		clientLocksMu.Unlock()
+
+		lock.Lock()
+		return lock.Unlock
+	}
+
+	// processClient runs process against the configured client once no other run of the client is in progress
+	processClient := func(ctx context.Context, client *config.FiringConfig, process func(ctx context.Context, client *firingservice.ConfiguredClient) error) {
+		targets, err := targetRepository.GetAllByClient(client.Id)
+		if err != nil {
+			logger.Errorf("Failed to get targets of client %d from repository: %v", client.Id, err)
+			return
+		}
+		userMappings, err := userMappingRepository.GetAllByClient(client.Id)
+		if err != nil {
+			logger.Errorf("Failed to get user mappings of client %d from repository: %v", client.Id, err)
+			return
+		}
+		escalationSteps, err := escalationStepRepository.GetAllByClient(client.Id)
+		if err != nil {
+			logger.Errorf("Failed to get escalation steps of client %d from repository: %v", client.Id, err)
+			return
+		}
+		configuredClient, err := configuredClientFactory.MakeClient(*client, targets, userMappings, escalationSteps)
+		if err != nil {
+			logger.Errorf("Failed to make configured client %d: %v", client.Id, err)
+			return
+		}
+		unlock := lockClient(client.Id)
+		defer unlock()
+
+		ctx, cancel := context.WithTimeout(ctx, clientTimeout)
+		defer cancel()
+
+		logger.Infof("Start processing client %d", configuredClient.Config.Id)
+		run := &config.ClientRun{ClientId: configuredClient.Config.Id}
+		if err := process(ctx, configuredClient); err != nil {
+			logger.Warnf("Failed to process client %d completely: %v", configuredClient.Config.Id, err)
+			run.Degraded = errors.Is(err, firingservice.ErrDegraded)
+			run.Error = err.Error()
+		}
+		if err := ctx.Err(); err != nil {
+			logger.Warnf("Processing of client %d is interrupted: %v", configuredClient.Config.Id, err)
+		}
+		run.FinishedAt = time.Now().UTC()
+		if err := clientRunRepository.Save(run); err != nil {
+			logger.Warnf("Failed to save run of client %d: %v", configuredClient.Config.Id, err)
+		}
+	}
+
+	hookCache := gitlabhook.NewCache()
+	hookReceiver := gitlabhook.NewReceiver(
+		hookCache,
+		func(clientId int, triggered []gitlabhook.TriggeredMergeRequest) {
+			mrs := make([]firingservice.MergeRequestChecks, len(triggered))
+			for i, mr := range triggered {
+				mrs[i] = firingservice.MergeRequestChecks{
+					MergeRequest: &gitlab.MergeRequest{ProjectID: mr.ProjectId, IID: mr.MergeRequestIid, State: mr.State},
+					Checks:       hookChecks(mr.Reasons),
+				}
+			}
+			sched.RunNow(func() {
+				client, err := clientRepository.Get(clientId)
+				if err != nil {
+					logger.Errorf("Failed to get client %d from repository: %v", clientId, err)
+					return
+				}
+				processClient(context.Background(), client, func(ctx context.Context, client *firingservice.ConfiguredClient) error {
+					return service.ProcessMergeRequests(ctx, client, mrs)
+				})
+			})
+		},
+		time.Duration(envutil.GetEnvUintOrDefault(internal.EnvGitlabHookDebounceSeconds, 30))*time.Second,
+	)
 
 	job := func() {
 		logger.Infof("Starting firing job")
@@ -84,15 +253,34 @@ func main() {
 			logger.Errorf("Failed to get clients from repository: %v", err)
 			return
 		}
-		for _, client := range clients {
-			configuredClient, err := configuredClientFactory.MakeClient(*client)
-			if err != nil {
-				logger.Errorf("Failed to make configured client %d: %v", client.Id, err)
-				return
+		retention := notificationRetention(clients)
+		if err := notificationLogRepository.DeleteNotifiedBefore(time.Now().UTC().Add(-retention)); err != nil {
+			logger.Warnf("Failed to clean up notification log: %v", err)
+		}
+		if err := notificationPostRepository.DeleteUpdatedBefore(time.Now().UTC().Add(-retention)); err != nil {
+			logger.Warnf("Failed to clean up notification posts: %v", err)
+		}
+		if err := notificationThreadRepository.DeleteUpdatedBefore(time.Now().UTC().Add(-retention)); err != nil {
+			logger.Warnf("Failed to clean up notification threads: %v", err)
+		}
+		if err := outboxRepository.DeleteFinishedBefore(time.Now().UTC().Add(-notificationLogRetention)); err != nil {
+			logger.Warnf("Failed to clean up outbox: %v", err)
+		}
+		if err := acknowledgementRepository.DeleteExpiredBefore(time.Now().UTC()); err != nil {
+			logger.Warnf("Failed to clean up expired acknowledgements: %v", err)
+		}
+		hookCache.DeleteUpdatedBefore(time.Now().UTC().Add(-notificationLogRetention))
+		if gitlabResponseCache != nil {
+			if err := gitlabResponseCache.DeleteUpdatedBefore(time.Now().UTC().Add(-gitlabResponseRetention)); err != nil {
+				logger.Warnf("Failed to clean up cached gitlab responses: %v", err)
 			}
-			logger.Infof("Start processing client %d", configuredClient.Config.Id)
-			service.ProcessConfig(configuredClient)
 		}
+		if discussionCache != nil {
+			discussionCache.DeleteExpired()
+		}
+		workerpool.Run(context.Background(), clientConcurrency, len(clients), func(ctx context.Context, i int) {
+			processClient(ctx, clients[i], service.ProcessConfig)
+		})
 		logger.Infof("Ending firing job")
 	}
 
@@ -102,7 +290,35 @@ func main() {
 
 	go sched.Run()
 
+	outboxIntervalSeconds := envutil.GetEnvUintOrDefault(internal.EnvOutboxIntervalSeconds, 10)
+	if outboxIntervalSeconds == 0 {
+		panic(fmt.Errorf("%s must be greater than 0", internal.EnvOutboxIntervalSeconds))
+	}
+	outboxMaxAttempts := envutil.GetEnvUintOrDefault(internal.EnvOutboxMaxAttempts, 8)
+	if outboxMaxAttempts == 0 {
+		panic(fmt.Errorf("%s must be greater than 0", internal.EnvOutboxMaxAttempts))
+	}
+	outboxWorker := outbox.NewWorker(
+		outboxRepository,
+		webhookResolver,
+		outbox.WorkerConfig{
+			Interval:    time.Duration(outboxIntervalSeconds) * time.Second,
+			MaxAttempts: int(outboxMaxAttempts),
+			BaseDelay:   outboxBaseRetryDelay,
+			MaxDelay:    outboxMaxRetryDelay,
+		},
+	)
+
+	go outboxWorker.Run()
+
 	clientController := controller.NewClientController(clientRepository)
+	clientRunController := controller.NewClientRunController(clientRunRepository)
+	targetController := controller.NewTargetController(targetRepository, webhookRegistry)
+	userMappingController := controller.NewUserMappingController(userMappingRepository)
+	escalationStepController := controller.NewEscalationStepController(escalationStepRepository, targetRepository)
+	outboxController := controller.NewOutboxController(outboxRepository)
+	commandController := controller.NewCommandController(clientRepository, userMappingRepository, escalationStepRepository, configuredClientFactory, service, clientTimeout)
+	gitlabHookController := controller.NewGitlabHookController(clientRepository, hookReceiver)
 
 	r := mux.NewRouter()
 	r.HandleFunc("/", RootHandler).Methods("GET")
@@ -111,6 +327,36 @@ func main() {
 	r.HandleFunc("/clients/{id:[0-9]+}", clientController.Get).Methods("GET")
 	r.HandleFunc("/clients/{id:[0-9]+}", clientController.Update).Methods("PUT")
 	r.HandleFunc("/clients/{id:[0-9]+}", clientController.Delete).Methods("DELETE")
+	r.HandleFunc("/clients/{id:[0-9]+}/runs/last", clientRunController.GetLast).Methods("GET")
+	r.HandleFunc("/clients/{id:[0-9]+}/targets", targetController.GetAll).Methods("GET")
+	r.HandleFunc("/clients/{id:[0-9]+}/targets", targetController.Create).Methods("POST")
+	r.HandleFunc("/clients/{id:[0-9]+}/targets/{target_id:[0-9]+}", targetController.Get).Methods("GET")
+	r.HandleFunc("/clients/{id:[0-9]+}/targets/{target_id:[0-9]+}", targetController.Update).Methods("PUT")
+	r.HandleFunc("/clients/{id:[0-9]+}/targets/{target_id:[0-9]+}", targetController.Delete).Methods("DELETE")
+	r.HandleFunc("/clients/{id:[0-9]+}/users", userMappingController.GetAll).Methods("GET")
+	r.HandleFunc("/clients/{id:[0-9]+}/users", userMappingController.Create).Methods("POST")
+	r.HandleFunc("/clients/{id:[0-9]+}/users/import", userMappingController.Import).Methods("POST")
+	r.HandleFunc("/clients/{id:[0-9]+}/users/{user_id:[0-9]+}", userMappingController.Get).Methods("GET")
+	r.HandleFunc("/clients/{id:[0-9]+}/users/{user_id:[0-9]+}", userMappingController.Update).Methods("PUT")
+	r.HandleFunc("/clients/{id:[0-9]+}/users/{user_id:[0-9]+}", userMappingController.Delete).Methods("DELETE")
+	r.HandleFunc("/clients/{id:[0-9]+}/escalations", escalationStepController.GetAll).Methods("GET")
+	r.HandleFunc("/clients/{id:[0-9]+}/escalations", escalationStepController.Create).Methods("POST")
+	r.HandleFunc("/clients/{id:[0-9]+}/escalations/{step_id:[0-9]+}", escalationStepController.Get).Methods("GET")
+	r.HandleFunc("/clients/{id:[0-9]+}/escalations/{step_id:[0-9]+}", escalationStepController.Update).Methods("PUT")
+	r.HandleFunc("/clients/{id:[0-9]+}/escalations/{step_id:[0-9]+}", escalationStepController.Delete).Methods("DELETE")
+	r.HandleFunc("/clients/{id:[0-9]+}/commands/review-status", commandController.ReviewStatus).Methods("POST")
+	r.HandleFunc("/gitlab/hooks", gitlabHookController.Receive).Methods("POST")
+	r.HandleFunc("/outbox/failed", outboxController.GetFailed).Methods("GET")
+	r.HandleFunc("/outbox/failed/replay", outboxController.ReplayFailed).Methods("POST")
+	r.HandleFunc("/outbox/{id:[0-9]+}/replay", outboxController.Replay).Methods("POST")
+	if buttons != nil {
+		slackSigningSecret := envutil.GetEnvStr(internal.EnvSlackSigningSecret)
+		interactionController := controller.NewInteractionController(acknowledgementRepository, userMappingRepository, webhookResolver, buttons, timeZone, schedulerConf.WorkdayStartAt, slackSigningSecret)
+		r.HandleFunc("/interactions/mattermost", interactionController.Mattermost).Methods("POST")
+		if len(slackSigningSecret) > 0 {
+			r.HandleFunc("/interactions/slack", interactionController.Slack).Methods("POST")
+		}
+	}
 
 	addr := ":8080"
 	logger.Infof("Starting at %s", addr)
@@ -120,6 +366,39 @@ func main() {
 
 }
 
+// hookChecks returns checks of a merge request which events may have changed the outcome of for the reasons,
+// closed merge requests only get their posts resolved
+func hookChecks(reasons map[string]bool) firingservice.Checks {
+	var checks firingservice.Checks
+	for reason := range reasons {
+		switch reason {
+		case gitlabhook.ReasonOpened:
+			checks.OldMergeRequest = true
+			checks.NeededReview = true
+		case gitlabhook.ReasonAssigned, gitlabhook.ReasonApproved, gitlabhook.ReasonPipelineFinished:
+			checks.NeededReview = true
+		case gitlabhook.ReasonAuthorReplied:
+			checks.Discussions = true
+		}
+	}
+	return checks
+}
+
+// notificationRetention returns how long notifications of the clients are remembered
+func notificationRetention(clients []*config.FiringConfig) time.Duration {
+	retention := notificationLogRetention
+	for _, client := range clients {
+		if len(client.RealertInterval) == 0 {
+			continue
+		}
+		// invalid intervals disable realerting and are reported when clients are processed
+		if interval, err := time.ParseDuration(client.RealertInterval); err == nil && interval > retention {
+			retention = interval
+		}
+	}
+	return retention
+}
+
 func RootHandler(w http.ResponseWriter, _ *http.Request) {
 	_, _ = fmt.Fprint(w, "ok")
 }
@@ -17,6 +17,7 @@ require (
 	github.com/jackc/pgx v3.6.2+incompatible
 	github.com/jasonlvhit/gocron v0.0.0-20200423141508-ab84337f7963
 	github.com/jmoiron/sqlx v1.2.0
+	github.com/lib/pq v1.3.0
 	github.com/mitchellh/copystructure v1.0.0 // indirect
 	github.com/rs/zerolog v1.18.0
 	github.com/xanzy/go-gitlab v0.31.0
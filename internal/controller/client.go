@@ -86,6 +86,12 @@ func (c *ClientController) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateClient(&client); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid client: %v", err)
+		return
+	}
+
 	if err := c.repo.Create(&client); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = fmt.Fprintf(w, "Failed to create client %d: %v", client.Id, err)
@@ -114,6 +120,12 @@ func (c *ClientController) Update(w http.ResponseWriter, r *http.Request) {
 	id := int(val)
 	client.Id = id
 
+	if err := validateClient(&client); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid client: %v", err)
+		return
+	}
+
 	if err := c.repo.Update(&client); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = fmt.Fprintf(w, "Failed to save client %d: %v", client.Id, err)
@@ -141,4 +153,17 @@ func (c *ClientController) Delete(w http.ResponseWriter, r *http.Request) {
 func maskClient(client *client2.FiringConfig) {
 	client.GitlabToken = "<MASKED>"
 	client.WebhookUrl = "<MASKED>"
+	client.WebhookToken = "<MASKED>"
+	client.WebhookSecret = "<MASKED>"
+	client.CommandToken = "<MASKED>"
+	client.GitlabHookToken = "<MASKED>"
+}
+
+func validateClient(client *client2.FiringConfig) error {
+	switch client.MergeRequestReviewMode {
+	case "", client2.ReviewModeParticipants, client2.ReviewModeApprovals:
+	default:
+		return fmt.Errorf("unknown merge_request_review_mode %s", client.MergeRequestReviewMode)
+	}
+	return nil
 }
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gitlab-code-review-notifier/internal/database"
+)
+
+type ClientRunController struct {
+	repo *database.ClientRunRepository
+}
+
+func NewClientRunController(repo *database.ClientRunRepository) *ClientRunController {
+	return &ClientRunController{repo: repo}
+}
+
+func (c *ClientRunController) GetLast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+
+	run, err := c.repo.Get(clientId)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "Run of client %d not found", clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get run of client %d: %v", clientId, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&run); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize run: %v", err)
+		return
+	}
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab-code-review-notifier/internal/database"
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/firingservice"
+	"gitlab-code-review-notifier/pkg/log"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+// CommandController handles mattermost and slack slash commands of clients
+type CommandController struct {
+	clients         *database.ClientRepository
+	userMappings    *database.UserMappingRepository
+	escalationSteps *database.EscalationStepRepository
+	clientFactory   *firingservice.ConfiguredClientFactory
+	service         *firingservice.FiringService
+	// timeout limits collecting the review status like processing of the client does
+	timeout time.Duration
+	log.Loggable
+}
+
+func NewCommandController(
+	clients *database.ClientRepository,
+	userMappings *database.UserMappingRepository,
+	escalationSteps *database.EscalationStepRepository,
+	clientFactory *firingservice.ConfiguredClientFactory,
+	service *firingservice.FiringService,
+	timeout time.Duration,
+) *CommandController {
+	return &CommandController{
+		clients:         clients,
+		userMappings:    userMappings,
+		escalationSteps: escalationSteps,
+		clientFactory:   clientFactory,
+		service:         service,
+		timeout:         timeout,
+	}
+}
+
+// ReviewStatus replies right away as collecting merge requests takes longer than chats wait for
+// and sends the review status of the caller to the response url once it is ready
+func (c *CommandController) ReviewStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+
+	client, err := c.clients.Get(id)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "Client id %d not found", id)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get client with id %d: %v", id, err)
+		return
+	}
+
+	token := r.FormValue("token")
+	if len(client.CommandToken) == 0 || subtle.ConstantTimeCompare([]byte(token), []byte(client.CommandToken)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprintf(w, "Invalid command token of client %d", id)
+		return
+	}
+
+	responseUrl := r.FormValue("response_url")
+	if len(responseUrl) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, "Response url is not set")
+		return
+	}
+
+	if err := webhook.VerifyCommandResponseUrl(client.WebhookType, client.WebhookUrl, responseUrl); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid response url: %v", err)
+		return
+	}
+
+	go c.respondReviewStatus(client, r.FormValue("user_id"), r.FormValue("user_name"), responseUrl)
+
+	resp := webhook.NewEphemeralCommandResponse(client.WebhookType, "Collecting review status...")
+	if err := json.NewEncoder(w).Encode(&resp); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize command response: %v", err)
+		return
+	}
+}
+
+func (c *CommandController) respondReviewStatus(client *config.FiringConfig, chatUserId string, chatUsername string, responseUrl string) {
+	text, err := c.renderReviewStatus(client, chatUserId, chatUsername)
+	if err != nil {
+		c.Log().Errorf("Failed to render review status of user %s in client %d: %v", chatUsername, client.Id, err)
+		text = "Failed to collect review status, please try again later"
+	}
+
+	if err := webhook.RespondCommand(responseUrl, webhook.NewEphemeralCommandResponse(client.WebhookType, text)); err != nil {
+		c.Log().Warnf("Failed to send review status to user %s in client %d: %v", chatUsername, client.Id, err)
+	}
+}
+
+func (c *CommandController) renderReviewStatus(client *config.FiringConfig, chatUserId string, chatUsername string) (string, error) {
+	userMappings, err := c.userMappings.GetAllByClient(client.Id)
+	if err != nil {
+		return "", fmt.Errorf("get user mappings: %v", err)
+	}
+
+	escalationSteps, err := c.escalationSteps.GetAllByClient(client.Id)
+	if err != nil {
+		return "", fmt.Errorf("get escalation steps: %v", err)
+	}
+
+	configuredClient, err := c.clientFactory.MakeClient(*client, nil, userMappings, escalationSteps)
+	if err != nil {
+		return "", fmt.Errorf("make configured client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	username := firingservice.ResolveGitlabUsername(userMappings, chatUserId, chatUsername)
+	return configuredClient.Notifier.RenderReviewStatus(c.service.ReviewStatus(ctx, configuredClient, username))
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab-code-review-notifier/internal/database"
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type EscalationStepController struct {
+	repo *database.EscalationStepRepository
+	// targets checks that steps send notifications to existing targets of their clients
+	targets *database.TargetRepository
+}
+
+func NewEscalationStepController(repo *database.EscalationStepRepository, targets *database.TargetRepository) *EscalationStepController {
+	return &EscalationStepController{repo: repo, targets: targets}
+}
+
+func (c *EscalationStepController) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, id, ok := parseEscalationStepIds(w, r)
+	if !ok {
+		return
+	}
+
+	step, err := c.repo.Get(clientId, id)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "Escalation step id %d of client %d not found", id, clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get escalation step with id %d of client %d: %v", id, clientId, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&step); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize escalation step with id %d: %v", id, err)
+		return
+	}
+}
+
+func (c *EscalationStepController) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+
+	steps, err := c.repo.GetAllByClient(clientId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get escalation steps of client %d: %v", clientId, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&steps); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize escalation steps: %v", err)
+		return
+	}
+}
+
+func (c *EscalationStepController) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var step config.EscalationStep
+	if err := json.NewDecoder(r.Body).Decode(&step); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to deserialize escalation step from request body: %v", err)
+		return
+	}
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+	step.ClientId = clientId
+
+	if err := validateEscalationStep(&step); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid escalation step: %v", err)
+		return
+	}
+
+	if !c.checkTarget(w, &step) {
+		return
+	}
+
+	if err := c.repo.Create(&step); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to create escalation step of client %d: %v", clientId, err)
+		return
+	}
+}
+
+func (c *EscalationStepController) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var step config.EscalationStep
+	if err := json.NewDecoder(r.Body).Decode(&step); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to deserialize escalation step from request body: %v", err)
+		return
+	}
+
+	clientId, id, ok := parseEscalationStepIds(w, r)
+	if !ok {
+		return
+	}
+	step.ClientId = clientId
+	step.Id = id
+
+	if err := validateEscalationStep(&step); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid escalation step: %v", err)
+		return
+	}
+
+	if !c.checkTarget(w, &step) {
+		return
+	}
+
+	err := c.repo.Update(&step)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "Escalation step id %d of client %d not found", id, clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to save escalation step %d of client %d: %v", id, clientId, err)
+		return
+	}
+}
+
+func (c *EscalationStepController) Delete(w http.ResponseWriter, r *http.Request) {
+	clientId, id, ok := parseEscalationStepIds(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.Delete(clientId, id); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to delete escalation step with id %d of client %d: %v", id, clientId, err)
+		return
+	}
+}
+
+func validateEscalationStep(step *config.EscalationStep) error {
+	timeout, err := time.ParseDuration(step.Timeout)
+	if err != nil {
+		return fmt.Errorf("parse timeout %s: %v", step.Timeout, err)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	return nil
+}
+
+// checkTarget verifies the target of the step belongs to its client writing an error response if it doesn't
+func (c *EscalationStepController) checkTarget(w http.ResponseWriter, step *config.EscalationStep) bool {
+	if step.TargetId == 0 {
+		return true
+	}
+
+	_, err := c.targets.Get(step.ClientId, step.TargetId)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid escalation step: target id %d of client %d not found", step.TargetId, step.ClientId)
+		return false
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get target with id %d of client %d: %v", step.TargetId, step.ClientId, err)
+		return false
+	}
+
+	return true
+}
+
+func parseEscalationStepIds(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return 0, 0, false
+	}
+	id, ok := parseIdVar(w, r, "step_id")
+	if !ok {
+		return 0, 0, false
+	}
+	return clientId, id, true
+}
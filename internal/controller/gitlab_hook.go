@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/internal/database"
+	"gitlab-code-review-notifier/pkg/gitlabhook"
+)
+
+// GitlabHookController receives merge request, note and pipeline events of gitlab webhooks,
+// the secret token of the webhook identifies clients the events are for
+type GitlabHookController struct {
+	clients  *database.ClientRepository
+	receiver *gitlabhook.Receiver
+}
+
+func NewGitlabHookController(clients *database.ClientRepository, receiver *gitlabhook.Receiver) *GitlabHookController {
+	return &GitlabHookController{clients: clients, receiver: receiver}
+}
+
+func (c *GitlabHookController) Receive(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Gitlab-Token")
+	if len(token) == 0 {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprint(w, "Gitlab token is not set")
+		return
+	}
+
+	clients, err := c.clients.GetAllByGitlabHookToken(token)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get clients by gitlab token: %v", err)
+		return
+	}
+
+	if len(clients) == 0 {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprint(w, "Invalid gitlab token")
+		return
+	}
+
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to read gitlab event: %v", err)
+		return
+	}
+
+	clientIds := make([]int, 0, len(clients))
+	for _, client := range clients {
+		clientIds = append(clientIds, client.Id)
+	}
+
+	if err := c.receiver.Receive(clientIds, gitlab.HookEventType(r), payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to receive gitlab event: %v", err)
+		return
+	}
+}
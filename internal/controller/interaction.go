@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab-code-review-notifier/internal/database"
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/firingservice"
+	"gitlab-code-review-notifier/pkg/interaction"
+	"gitlab-code-review-notifier/pkg/log"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+// InteractionController handles clicks on alert buttons sent by chats
+type InteractionController struct {
+	repo *database.AcknowledgementRepository
+	// userMappings and webhooks resolve mattermost users by their ids as names in requests are not verified
+	userMappings *database.UserMappingRepository
+	webhooks     *firingservice.WebhookResolver
+	buttons      *interaction.Buttons
+	// timeZone and workdayStartAt define when alerts snoozed until tomorrow are resumed
+	timeZone       *time.Location
+	workdayStartAt int
+	// slackSigningSecret verifies that clicks are sent by the slack app
+	slackSigningSecret string
+	log.Loggable
+}
+
+func NewInteractionController(
+	repo *database.AcknowledgementRepository,
+	userMappings *database.UserMappingRepository,
+	webhooks *firingservice.WebhookResolver,
+	buttons *interaction.Buttons,
+	timeZone *time.Location,
+	workdayStartAt int,
+	slackSigningSecret string,
+) *InteractionController {
+	return &InteractionController{
+		repo:               repo,
+		userMappings:       userMappings,
+		webhooks:           webhooks,
+		buttons:            buttons,
+		timeZone:           timeZone,
+		workdayStartAt:     workdayStartAt,
+		slackSigningSecret: slackSigningSecret,
+	}
+}
+
+func (c *InteractionController) Mattermost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req webhook.MattermostActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to deserialize mattermost action from request body: %v", err)
+		return
+	}
+
+	ack, item, ok := c.acknowledge(w, req.Context.Value, func(item interaction.Item) string {
+		return c.mattermostUserName(item.ClientId, req.UserId)
+	})
+	if !ok {
+		return
+	}
+
+	resp := webhook.NewMattermostActionResponse(req, interaction.Status(ack, c.timeZone), c.buttons.Make(item))
+	if err := json.NewEncoder(w).Encode(&resp); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize mattermost action response: %v", err)
+		return
+	}
+}
+
+func (c *InteractionController) Slack(w http.ResponseWriter, r *http.Request) {
+	payload, err := webhook.ParseSlackInteraction(r, c.slackSigningSecret)
+	if errors.Is(err, webhook.ErrInvalidSlackSignature) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprintf(w, "Failed to verify slack interaction: %v", err)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to parse slack interaction: %v", err)
+		return
+	}
+
+	if len(payload.Actions) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, "Slack interaction has no actions")
+		return
+	}
+
+	// the user is taken from the payload only once slack signature of the request is verified
+	ack, _, ok := c.acknowledge(w, payload.Actions[0].Value, func(interaction.Item) string {
+		return payload.User.Username
+	})
+	if !ok {
+		return
+	}
+
+	if err := webhook.RespondSlackInteraction(payload, interaction.Status(ack, c.timeZone)); err != nil {
+		c.Log().Warnf("Failed to update slack message of merge request %d in project %d: %v", ack.MergeRequestIid, ack.ProjectId, err)
+	}
+}
+
+// acknowledge verifies the value of the clicked button and saves the acknowledgement of its item
+// by the user userName returns once the value is verified
+func (c *InteractionController) acknowledge(w http.ResponseWriter, value string, userName func(item interaction.Item) string) (*config.Acknowledgement, interaction.Item, bool) {
+	now := time.Now().UTC()
+	action, item, err := c.buttons.Parse(value, now)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprintf(w, "Failed to verify button value: %v", err)
+		return nil, item, false
+	}
+
+	ack, err := interaction.NewAcknowledgement(action, item, userName(item), now, c.tomorrow(now))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid button action: %v", err)
+		return nil, item, false
+	}
+
+	if err := c.repo.Save(ack); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to save acknowledgement of merge request %d in project %d: %v", item.MergeRequestIid, item.ProjectId, err)
+		return nil, item, false
+	}
+
+	return ack, item, true
+}
+
+// mattermostUserName returns the chat username of the mattermost user according to user mappings of the client
+// or the mattermost api of its bot, empty if the user can't be resolved
+func (c *InteractionController) mattermostUserName(clientId int, userId string) string {
+	if len(userId) == 0 {
+		return ""
+	}
+
+	mappings, err := c.userMappings.GetAllByClient(clientId)
+	if err != nil {
+		c.Log().Warnf("Failed to get user mappings of client %d: %v", clientId, err)
+	}
+	for _, mapping := range mappings {
+		if mapping.ChatUserId == userId && len(mapping.ChatUsername) > 0 {
+			return mapping.ChatUsername
+		}
+	}
+
+	hook, err := c.webhooks.Resolve(clientId, 0)
+	if err != nil {
+		c.Log().Warnf("Failed to make webhook of client %d: %v", clientId, err)
+		return ""
+	}
+	resolver, ok := hook.(webhook.UserNameResolver)
+	if !ok {
+		return ""
+	}
+	userName, err := resolver.GetUsername(userId)
+	if err != nil {
+		c.Log().Warnf("Failed to get mattermost user %s of client %d: %v", userId, clientId, err)
+		return ""
+	}
+	return userName
+}
+
+// tomorrow returns the moment the workday starts on the next day in UTC
+func (c *InteractionController) tomorrow(now time.Time) time.Time {
+	local := now.In(c.timeZone).AddDate(0, 0, 1)
+	return time.Date(local.Year(), local.Month(), local.Day(), c.workdayStartAt, 0, 0, 0, c.timeZone).UTC()
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gitlab-code-review-notifier/internal/database"
+)
+
+type OutboxController struct {
+	repo *database.OutboxRepository
+}
+
+func NewOutboxController(repo *database.OutboxRepository) *OutboxController {
+	return &OutboxController{repo: repo}
+}
+
+func (c *OutboxController) GetFailed(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	entries, err := c.repo.GetAllFailed()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get failed outbox entries: %v", err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&entries); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize outbox entries: %v", err)
+		return
+	}
+}
+
+func (c *OutboxController) Replay(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+
+	err := c.repo.Replay(id)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "Failed outbox entry id %d not found", id)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to replay outbox entry with id %d: %v", id, err)
+		return
+	}
+}
+
+func (c *OutboxController) ReplayFailed(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	count, err := c.repo.ReplayAllFailed()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to replay failed outbox entries: %v", err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]int64{"replayed": count}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize replayed count: %v", err)
+		return
+	}
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"gitlab-code-review-notifier/internal/database"
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/firingservice"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+type TargetController struct {
+	repo *database.TargetRepository
+	// webhookRegistry checks that webhooks of targets can be made before they are saved
+	webhookRegistry *webhook.Registry
+}
+
+func NewTargetController(repo *database.TargetRepository, webhookRegistry *webhook.Registry) *TargetController {
+	return &TargetController{repo: repo, webhookRegistry: webhookRegistry}
+}
+
+func (c *TargetController) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, id, ok := parseTargetIds(w, r)
+	if !ok {
+		return
+	}
+
+	target, err := c.repo.Get(clientId, id)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "Target id %d of client %d not found", id, clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get target with id %d of client %d: %v", id, clientId, err)
+		return
+	}
+
+	maskTarget(target)
+
+	if err := json.NewEncoder(w).Encode(&target); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize target with id %d: %v", id, err)
+		return
+	}
+}
+
+func (c *TargetController) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+
+	targets, err := c.repo.GetAllByClient(clientId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get targets of client %d: %v", clientId, err)
+		return
+	}
+
+	for _, target := range targets {
+		maskTarget(target)
+	}
+
+	if err := json.NewEncoder(w).Encode(&targets); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize targets: %v", err)
+		return
+	}
+}
+
+func (c *TargetController) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var target config.Target
+	if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to deserialize target from request body: %v", err)
+		return
+	}
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+	target.ClientId = clientId
+
+	if err := c.validateTarget(&target); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid target: %v", err)
+		return
+	}
+
+	if err := c.repo.Create(&target); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to create target of client %d: %v", clientId, err)
+		return
+	}
+}
+
+func (c *TargetController) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var target config.Target
+	if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to deserialize target from request body: %v", err)
+		return
+	}
+
+	clientId, id, ok := parseTargetIds(w, r)
+	if !ok {
+		return
+	}
+	target.ClientId = clientId
+	target.Id = id
+
+	if err := c.validateTarget(&target); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid target: %v", err)
+		return
+	}
+
+	err := c.repo.Update(&target)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "Target id %d of client %d not found", id, clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to save target %d of client %d: %v", id, clientId, err)
+		return
+	}
+}
+
+func (c *TargetController) Delete(w http.ResponseWriter, r *http.Request) {
+	clientId, id, ok := parseTargetIds(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.Delete(clientId, id); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to delete target with id %d of client %d: %v", id, clientId, err)
+		return
+	}
+}
+
+func (c *TargetController) validateTarget(target *config.Target) error {
+	for _, kind := range target.Kinds {
+		switch kind {
+		case config.KindOldMergeRequest, config.KindNeededReviewMergeRequest, config.KindFiringDiscussion:
+		default:
+			return fmt.Errorf("unknown notification kind %s", kind)
+		}
+	}
+	if _, err := c.webhookRegistry.Make(target.WebhookType, firingservice.TargetWebhookSettings(target)); err != nil {
+		return fmt.Errorf("invalid webhook: %v", err)
+	}
+	return nil
+}
+
+func parseTargetIds(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return 0, 0, false
+	}
+	id, ok := parseIdVar(w, r, "target_id")
+	if !ok {
+		return 0, 0, false
+	}
+	return clientId, id, true
+}
+
+// parseIdVar parses an integer path variable writing bad request response if it fails
+func parseIdVar(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	vars := mux.Vars(r)
+	val, err := strconv.ParseInt(vars[name], 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to parse :%s from value %s: %v", name, vars[name], err)
+		return 0, false
+	}
+	return int(val), true
+}
+
+func maskTarget(target *config.Target) {
+	target.WebhookUrl = "<MASKED>"
+	target.WebhookToken = "<MASKED>"
+	target.WebhookSecret = "<MASKED>"
+}
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitlab-code-review-notifier/internal/database"
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type UserMappingController struct {
+	repo *database.UserMappingRepository
+}
+
+func NewUserMappingController(repo *database.UserMappingRepository) *UserMappingController {
+	return &UserMappingController{repo: repo}
+}
+
+func (c *UserMappingController) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, id, ok := parseUserMappingIds(w, r)
+	if !ok {
+		return
+	}
+
+	mapping, err := c.repo.Get(clientId, id)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "User mapping id %d of client %d not found", id, clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get user mapping with id %d of client %d: %v", id, clientId, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&mapping); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize user mapping with id %d: %v", id, err)
+		return
+	}
+}
+
+func (c *UserMappingController) GetAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+
+	mappings, err := c.repo.GetAllByClient(clientId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to get user mappings of client %d: %v", clientId, err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(&mappings); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to serialize user mappings: %v", err)
+		return
+	}
+}
+
+func (c *UserMappingController) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var mapping config.UserMapping
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to deserialize user mapping from request body: %v", err)
+		return
+	}
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+	mapping.ClientId = clientId
+
+	if err := validateUserMapping(&mapping); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid user mapping: %v", err)
+		return
+	}
+
+	err := c.repo.Create(&mapping)
+
+	if err == database.ErrConflict {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprintf(w, "User mapping of gitlab user %s already exists in client %d", mapping.GitlabUsername, clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to create user mapping of client %d: %v", clientId, err)
+		return
+	}
+}
+
+func (c *UserMappingController) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var mapping config.UserMapping
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to deserialize user mapping from request body: %v", err)
+		return
+	}
+
+	clientId, id, ok := parseUserMappingIds(w, r)
+	if !ok {
+		return
+	}
+	mapping.ClientId = clientId
+	mapping.Id = id
+
+	if err := validateUserMapping(&mapping); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Invalid user mapping: %v", err)
+		return
+	}
+
+	err := c.repo.Update(&mapping)
+
+	if err == database.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "User mapping id %d of client %d not found", id, clientId)
+		return
+	}
+
+	if err == database.ErrConflict {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprintf(w, "User mapping of gitlab user %s already exists in client %d", mapping.GitlabUsername, clientId)
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to save user mapping %d of client %d: %v", id, clientId, err)
+		return
+	}
+}
+
+func (c *UserMappingController) Delete(w http.ResponseWriter, r *http.Request) {
+	clientId, id, ok := parseUserMappingIds(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.Delete(clientId, id); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to delete user mapping with id %d of client %d: %v", id, clientId, err)
+		return
+	}
+}
+
+// Import creates or updates user mappings from CSV with
+// gitlab_username,chat_username[,gitlab_user_id[,chat_user_id]] columns and an optional header
+func (c *UserMappingController) Import(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return
+	}
+
+	mappings, err := parseUserMappingsCsv(r.Body, clientId)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "Failed to parse user mappings CSV: %v", err)
+		return
+	}
+
+	if err := c.repo.Upsert(mappings); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "Failed to import user mappings of client %d: %v", clientId, err)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, `{"imported":%d}`, len(mappings))
+}
+
+func parseUserMappingsCsv(body io.Reader, clientId int) ([]*config.UserMapping, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	mappings := make([]*config.UserMapping, 0)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return mappings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "gitlab_username") {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected at least gitlab_username and chat_username", line)
+		}
+
+		mapping := &config.UserMapping{
+			ClientId:       clientId,
+			GitlabUsername: strings.TrimSpace(record[0]),
+			ChatUsername:   strings.TrimSpace(record[1]),
+		}
+		if len(record) > 2 && len(strings.TrimSpace(record[2])) > 0 {
+			if mapping.GitlabUserId, err = strconv.Atoi(strings.TrimSpace(record[2])); err != nil {
+				return nil, fmt.Errorf("line %d: parse gitlab_user_id: %v", line, err)
+			}
+		}
+		if len(record) > 3 {
+			mapping.ChatUserId = strings.TrimSpace(record[3])
+		}
+
+		if err := validateUserMapping(mapping); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		mappings = append(mappings, mapping)
+	}
+}
+
+func validateUserMapping(mapping *config.UserMapping) error {
+	mapping.ChatUsername = strings.TrimPrefix(mapping.ChatUsername, "@")
+	if len(mapping.GitlabUsername) == 0 {
+		return fmt.Errorf("gitlab_username is not set")
+	}
+	if len(mapping.ChatUsername) == 0 {
+		return fmt.Errorf("chat_username is not set")
+	}
+	return nil
+}
+
+func parseUserMappingIds(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	clientId, ok := parseIdVar(w, r, "id")
+	if !ok {
+		return 0, 0, false
+	}
+	id, ok := parseIdVar(w, r, "user_id")
+	if !ok {
+		return 0, 0, false
+	}
+	return clientId, id, true
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type AcknowledgementRepository struct {
+	db *db
+}
+
+func NewAcknowledgementRepository(db *db) *AcknowledgementRepository {
+	return &AcknowledgementRepository{db: db}
+}
+
+// FindActive returns the acknowledgement of the item or the ignore of its whole merge request which hasn't expired at the time
+// or nil if there is no such acknowledgement
+func (r *AcknowledgementRepository) FindActive(key config.Acknowledgement, now time.Time) (*config.Acknowledgement, error) {
+	var acks []*config.Acknowledgement
+	err := r.db.Select(&acks, `
+			select * from acknowledgements
+			where client_id=$1 and project_id=$2 and merge_request_iid=$3
+				and ((discussion_id=$4 and kind=$5) or (discussion_id='' and kind=$6))
+				and (until is null or until > $7)
+			order by kind=$6`,
+		key.ClientId, key.ProjectId, key.MergeRequestIid, key.DiscussionId, key.Kind, config.AckKindAll, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(acks) == 0 {
+		return nil, nil
+	}
+
+	return acks[0], nil
+}
+
+func (r *AcknowledgementRepository) Save(ack *config.Acknowledgement) error {
+	_, err := r.db.NamedExec(`insert into
+			acknowledgements(
+				client_id,
+				project_id,
+				merge_request_iid,
+				discussion_id,
+				kind,
+				action,
+				user_name,
+				until,
+				created_at
+			)
+			values (
+				:client_id,
+				:project_id,
+				:merge_request_iid,
+				:discussion_id,
+				:kind,
+				:action,
+				:user_name,
+				:until,
+				:created_at
+			)
+			on conflict (client_id, project_id, merge_request_iid, discussion_id, kind) do update set
+				action=excluded.action,
+				user_name=excluded.user_name,
+				until=excluded.until,
+				created_at=excluded.created_at`,
+		ack)
+
+	return err
+}
+
+// DeleteExpiredBefore removes acknowledgements which expired before the time
+func (r *AcknowledgementRepository) DeleteExpiredBefore(t time.Time) error {
+	_, err := r.db.Exec(`delete from acknowledgements where until < $1`, t)
+	return err
+}
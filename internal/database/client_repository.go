@@ -9,6 +9,8 @@ import (
 
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when an entity duplicates a unique field of an existing one
+	ErrConflict = errors.New("conflict")
 )
 
 type ClientRepository struct {
@@ -38,6 +40,12 @@ func (r *ClientRepository) GetAll() ([]*config.FiringConfig, error) {
 	return clients, r.db.Select(&clients, `select * from clients`)
 }
 
+// GetAllByGitlabHookToken returns clients whose gitlab webhooks are set up with the token
+func (r *ClientRepository) GetAllByGitlabHookToken(token string) ([]*config.FiringConfig, error) {
+	clients := make([]*config.FiringConfig, 0)
+	return clients, r.db.Select(&clients, `select * from clients where gitlab_hook_token=$1 and gitlab_hook_token<>''`, token)
+}
+
 func (r *ClientRepository) Create(config *config.FiringConfig) error {
 	config.CreatedAt = time.Now()
 	config.UpdatedAt = time.Now()
@@ -46,26 +54,50 @@ func (r *ClientRepository) Create(config *config.FiringConfig) error {
 			clients(
 				group_id,
 				gitlab_token,
+				webhook_type,
 				webhook_url,
+				webhook_token,
+				webhook_channel,
+				webhook_secret,
+				delivery_mode,
+				repeat_mode,
 				discussion_firing_timeout,
 				merge_request_old_timeout,
 				merge_request_old_mention,
 				merge_request_review_timeout,
 				merge_request_reviewers_count,
 				merge_request_review_mention,
+				merge_request_review_mode,
+				realert_interval,
+				digest_mode,
+				command_token,
+				gitlab_hook_token,
+				merge_requests_limit,
 				created_at,
 				updated_at
 			)
 			values (
 				:group_id,
 				:gitlab_token,
+				:webhook_type,
 				:webhook_url,
+				:webhook_token,
+				:webhook_channel,
+				:webhook_secret,
+				:delivery_mode,
+				:repeat_mode,
 				:discussion_firing_timeout,
 				:merge_request_old_timeout,
 				:merge_request_old_mention,
 				:merge_request_review_timeout,
 				:merge_request_reviewers_count,
 				:merge_request_review_mention,
+				:merge_request_review_mode,
+				:realert_interval,
+				:digest_mode,
+				:command_token,
+				:gitlab_hook_token,
+				:merge_requests_limit,
 				:created_at,
 				:updated_at
 			)`,
@@ -81,13 +113,25 @@ func (r *ClientRepository) Update(config *config.FiringConfig) error {
 			update clients set
 				group_id=:group_id,
 				gitlab_token=:gitlab_token,
+				webhook_type=:webhook_type,
 				webhook_url=:webhook_url,
+				webhook_token=:webhook_token,
+				webhook_channel=:webhook_channel,
+				webhook_secret=:webhook_secret,
+				delivery_mode=:delivery_mode,
+				repeat_mode=:repeat_mode,
 				discussion_firing_timeout=:discussion_firing_timeout,
 				merge_request_old_timeout=:merge_request_old_timeout,
 				merge_request_old_mention=:merge_request_old_mention,
 				merge_request_review_timeout=:merge_request_review_timeout,
 				merge_request_reviewers_count=:merge_request_reviewers_count,
 				merge_request_review_mention=:merge_request_review_mention,
+				merge_request_review_mode=:merge_request_review_mode,
+				realert_interval=:realert_interval,
+				digest_mode=:digest_mode,
+				command_token=:command_token,
+				gitlab_hook_token=:gitlab_hook_token,
+				merge_requests_limit=:merge_requests_limit,
 				updated_at=:updated_at
 			where id=:id`,
 		config)
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type ClientRunRepository struct {
+	db *db
+}
+
+func NewClientRunRepository(db *db) *ClientRunRepository {
+	return &ClientRunRepository{db: db}
+}
+
+// Get returns the last run of the client
+func (r *ClientRunRepository) Get(clientId int) (*config.ClientRun, error) {
+	var runs []*config.ClientRun
+	if err := r.db.Select(&runs, `select * from client_runs where client_id=$1`, clientId); err != nil {
+		return nil, err
+	}
+
+	if len(runs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return runs[0], nil
+}
+
+// Save replaces the last run of the client
+func (r *ClientRunRepository) Save(run *config.ClientRun) error {
+	_, err := r.db.NamedExec(`insert into
+			client_runs(
+				client_id,
+				degraded,
+				error,
+				finished_at
+			)
+			values (
+				:client_id,
+				:degraded,
+				:error,
+				:finished_at
+			)
+			on conflict (client_id) do update set
+				degraded=excluded.degraded,
+				error=excluded.error,
+				finished_at=excluded.finished_at`,
+		run)
+
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jackc/pgx"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"gitlab-code-review-notifier/internal"
 	"gitlab-code-review-notifier/pkg/envutil"
@@ -72,3 +73,14 @@ func (d *db) Migrate() error {
 	}
 	return nil
 }
+
+// uniqueViolation is the postgres error code of inserts and updates which break a unique constraint
+const uniqueViolation = "23505"
+
+// conflictError returns ErrConflict if err is a violation of a unique constraint or err itself otherwise
+func conflictError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return ErrConflict
+	}
+	return err
+}
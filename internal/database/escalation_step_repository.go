@@ -0,0 +1,94 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type EscalationStepRepository struct {
+	db *db
+}
+
+func NewEscalationStepRepository(db *db) *EscalationStepRepository {
+	return &EscalationStepRepository{db: db}
+}
+
+func (r *EscalationStepRepository) Get(clientId int, id int) (*config.EscalationStep, error) {
+	var steps []*config.EscalationStep
+	err := r.db.Select(&steps, `select * from escalation_steps where client_id=$1 and id=$2`, clientId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(steps) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return steps[0], nil
+}
+
+// GetAllByClient returns steps of the client in the order of the escalation policy
+func (r *EscalationStepRepository) GetAllByClient(clientId int) ([]*config.EscalationStep, error) {
+	steps := make([]*config.EscalationStep, 0)
+	return steps, r.db.Select(&steps, `select * from escalation_steps where client_id=$1 order by position, id`, clientId)
+}
+
+func (r *EscalationStepRepository) Create(step *config.EscalationStep) error {
+	step.CreatedAt = time.Now()
+	step.UpdatedAt = time.Now()
+
+	_, err := r.db.NamedExec(`insert into
+			escalation_steps(
+				client_id,
+				position,
+				timeout,
+				mention,
+				target_id,
+				color,
+				created_at,
+				updated_at
+			)
+			values (
+				:client_id,
+				:position,
+				:timeout,
+				:mention,
+				:target_id,
+				:color,
+				:created_at,
+				:updated_at
+			)`,
+		step)
+
+	return err
+}
+
+func (r *EscalationStepRepository) Update(step *config.EscalationStep) error {
+	step.UpdatedAt = time.Now()
+
+	res, err := r.db.NamedExec(`
+			update escalation_steps set
+				position=:position,
+				timeout=:timeout,
+				mention=:mention,
+				target_id=:target_id,
+				color=:color,
+				updated_at=:updated_at
+			where id=:id and client_id=:client_id`,
+		step)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *EscalationStepRepository) Delete(clientId int, id int) error {
+	_, err := r.db.Exec(`delete from escalation_steps where client_id=$1 and id=$2`, clientId, id)
+	return err
+}
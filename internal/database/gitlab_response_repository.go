@@ -0,0 +1,61 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type GitlabResponseRepository struct {
+	db *db
+}
+
+func NewGitlabResponseRepository(db *db) *GitlabResponseRepository {
+	return &GitlabResponseRepository{db: db}
+}
+
+// Find returns the response with the key or nil if there is no such response
+func (r *GitlabResponseRepository) Find(key string) (*config.GitlabResponse, error) {
+	var responses []*config.GitlabResponse
+	if err := r.db.Select(&responses, `select * from gitlab_responses where key=$1`, key); err != nil {
+		return nil, err
+	}
+
+	if len(responses) == 0 {
+		return nil, nil
+	}
+
+	return responses[0], nil
+}
+
+func (r *GitlabResponseRepository) Save(response *config.GitlabResponse) error {
+	_, err := r.db.NamedExec(`insert into
+			gitlab_responses(
+				key,
+				etag,
+				header,
+				body,
+				updated_at
+			)
+			values (
+				:key,
+				:etag,
+				:header,
+				:body,
+				:updated_at
+			)
+			on conflict (key) do update set
+				etag=excluded.etag,
+				header=excluded.header,
+				body=excluded.body,
+				updated_at=excluded.updated_at`,
+		response)
+
+	return err
+}
+
+// DeleteUpdatedBefore removes responses which haven't changed since the time
+func (r *GitlabResponseRepository) DeleteUpdatedBefore(t time.Time) error {
+	_, err := r.db.Exec(`delete from gitlab_responses where updated_at < $1`, t)
+	return err
+}
@@ -0,0 +1,5 @@
+begin;
+
+alter table clients add column webhook_type varchar(20) not null default 'mattermost';
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+alter table clients alter column webhook_url type varchar(100);
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+alter table clients drop column webhook_token;
+alter table clients drop column webhook_channel;
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+alter table clients add column webhook_token varchar(200) not null default '';
+alter table clients add column webhook_channel varchar(100) not null default '';
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+alter table clients add column webhook_secret varchar(200) not null default '';
+
+commit;
@@ -0,0 +1,21 @@
+begin;
+
+create table if not exists client_targets
+(
+    id              integer primary key generated by default as identity,
+    client_id       integer      not null references clients (id) on delete cascade,
+    name            varchar(100) not null default '',
+    webhook_type    varchar(20)  not null default 'mattermost',
+    webhook_url     varchar(500) not null default '',
+    webhook_token   varchar(200) not null default '',
+    webhook_channel varchar(100) not null default '',
+    webhook_secret  varchar(200) not null default '',
+    kinds           varchar(200) not null default '',
+    project_ids     text         not null default '',
+    created_at      timestamp    not null,
+    updated_at      timestamp    not null
+);
+
+create index if not exists client_targets_client_id_idx on client_targets (client_id);
+
+commit;
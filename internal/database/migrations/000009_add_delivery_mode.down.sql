@@ -0,0 +1,5 @@
+begin;
+
+alter table clients drop column delivery_mode;
+
+commit;
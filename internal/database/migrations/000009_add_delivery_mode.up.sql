@@ -0,0 +1,5 @@
+begin;
+
+alter table clients add column delivery_mode varchar(20) not null default 'channel';
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+drop table user_mappings;
+
+commit;
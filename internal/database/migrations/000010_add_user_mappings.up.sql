@@ -0,0 +1,16 @@
+begin;
+
+create table if not exists user_mappings
+(
+    id              integer primary key generated by default as identity,
+    client_id       integer      not null references clients (id) on delete cascade,
+    gitlab_user_id  integer      not null default 0,
+    gitlab_username varchar(100) not null,
+    chat_username   varchar(100) not null,
+    chat_user_id    varchar(100) not null default '',
+    created_at      timestamp    not null,
+    updated_at      timestamp    not null,
+    unique (client_id, gitlab_username)
+);
+
+commit;
@@ -0,0 +1,7 @@
+begin;
+
+drop table notification_log;
+
+alter table clients drop column realert_interval;
+
+commit;
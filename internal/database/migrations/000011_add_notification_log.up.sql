@@ -0,0 +1,17 @@
+begin;
+
+alter table clients add column realert_interval varchar(10) not null default '';
+
+create table if not exists notification_log
+(
+    client_id         integer      not null references clients (id) on delete cascade,
+    kind              varchar(50)  not null,
+    project_id        integer      not null,
+    merge_request_iid integer      not null,
+    discussion_id     varchar(100) not null default '',
+    state             varchar(64)  not null,
+    notified_at       timestamp    not null,
+    primary key (client_id, kind, project_id, merge_request_iid, discussion_id)
+);
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+alter table clients drop column digest_mode;
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+alter table clients add column digest_mode boolean not null default false;
+
+commit;
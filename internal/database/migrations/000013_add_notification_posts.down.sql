@@ -0,0 +1,7 @@
+begin;
+
+drop table notification_posts;
+
+alter table clients drop column repeat_mode;
+
+commit;
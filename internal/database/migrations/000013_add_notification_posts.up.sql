@@ -0,0 +1,21 @@
+begin;
+
+alter table clients add column repeat_mode varchar(20) not null default 'post';
+
+create table if not exists notification_posts
+(
+    client_id         integer      not null references clients (id) on delete cascade,
+    target_id         integer      not null default 0,
+    kind              varchar(50)  not null,
+    project_id        integer      not null,
+    merge_request_iid integer      not null,
+    discussion_id     varchar(100) not null default '',
+    post_id           varchar(100) not null,
+    text              text         not null default '',
+    resolved          boolean      not null default false,
+    created_at        timestamp    not null,
+    updated_at        timestamp    not null,
+    primary key (client_id, target_id, kind, project_id, merge_request_iid, discussion_id)
+);
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+drop table notification_threads;
+
+commit;
@@ -0,0 +1,15 @@
+begin;
+
+create table if not exists notification_threads
+(
+    client_id         integer      not null references clients (id) on delete cascade,
+    target_id         integer      not null default 0,
+    project_id        integer      not null,
+    merge_request_iid integer      not null,
+    root_post_id      varchar(100) not null,
+    created_at        timestamp    not null,
+    updated_at        timestamp    not null,
+    primary key (client_id, target_id, project_id, merge_request_iid)
+);
+
+commit;
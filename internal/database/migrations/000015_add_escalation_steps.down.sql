@@ -0,0 +1,5 @@
+begin;
+
+drop table escalation_steps;
+
+commit;
@@ -0,0 +1,18 @@
+begin;
+
+create table if not exists escalation_steps
+(
+    id         integer primary key generated by default as identity,
+    client_id  integer      not null references clients (id) on delete cascade,
+    position   integer      not null default 0,
+    timeout    varchar(10)  not null,
+    mention    varchar(200) not null default '',
+    target_id  integer      not null default 0,
+    color      varchar(20)  not null default '',
+    created_at timestamp    not null,
+    updated_at timestamp    not null
+);
+
+create index if not exists escalation_steps_client_id_idx on escalation_steps (client_id);
+
+commit;
@@ -0,0 +1,19 @@
+begin;
+
+create table if not exists outbox
+(
+    id              integer primary key generated by default as identity,
+    client_id       integer     not null references clients (id) on delete cascade,
+    target_id       integer     not null default 0,
+    payload         text        not null,
+    status          varchar(20) not null default 'pending',
+    attempts        integer     not null default 0,
+    last_error      text        not null default '',
+    next_attempt_at timestamp   not null,
+    created_at      timestamp   not null,
+    updated_at      timestamp   not null
+);
+
+create index if not exists outbox_status_next_attempt_at_idx on outbox (status, next_attempt_at);
+
+commit;
@@ -0,0 +1,16 @@
+begin;
+
+create table if not exists acknowledgements
+(
+    client_id         integer      not null references clients (id) on delete cascade,
+    project_id        integer      not null,
+    merge_request_iid integer      not null,
+    discussion_id     varchar(100) not null default '',
+    action            varchar(20)  not null,
+    user_name         varchar(100) not null default '',
+    until             timestamp,
+    created_at        timestamp    not null,
+    primary key (client_id, project_id, merge_request_iid, discussion_id)
+);
+
+commit;
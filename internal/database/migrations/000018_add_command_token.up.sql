@@ -0,0 +1,5 @@
+begin;
+
+alter table clients add column command_token varchar(100) not null default '';
+
+commit;
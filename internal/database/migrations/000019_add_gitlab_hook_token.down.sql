@@ -0,0 +1,7 @@
+begin;
+
+drop index clients_gitlab_hook_token_idx;
+
+alter table clients drop column gitlab_hook_token;
+
+commit;
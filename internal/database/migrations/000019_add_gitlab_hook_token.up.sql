@@ -0,0 +1,7 @@
+begin;
+
+alter table clients add column gitlab_hook_token varchar(100) not null default '';
+
+create index clients_gitlab_hook_token_idx on clients (gitlab_hook_token);
+
+commit;
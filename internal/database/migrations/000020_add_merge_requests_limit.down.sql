@@ -0,0 +1,5 @@
+begin;
+
+alter table clients drop column merge_requests_limit;
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+alter table clients add column merge_requests_limit int not null default 0;
+
+commit;
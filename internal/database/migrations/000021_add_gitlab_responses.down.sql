@@ -0,0 +1,5 @@
+begin;
+
+drop table gitlab_responses;
+
+commit;
@@ -0,0 +1,12 @@
+begin;
+
+create table if not exists gitlab_responses
+(
+    key        varchar(64) primary key,
+    etag       text        not null,
+    header     text        not null default '',
+    body       bytea       not null,
+    updated_at timestamp   not null
+);
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+alter table clients drop column merge_request_review_mode;
+
+commit;
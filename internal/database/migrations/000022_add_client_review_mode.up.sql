@@ -0,0 +1,5 @@
+begin;
+
+alter table clients add column merge_request_review_mode varchar(20) not null default 'participants';
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+drop table client_runs;
+
+commit;
@@ -0,0 +1,11 @@
+begin;
+
+create table if not exists client_runs
+(
+    client_id   integer   not null primary key references clients (id) on delete cascade,
+    degraded    boolean   not null default false,
+    error       text      not null default '',
+    finished_at timestamp not null
+);
+
+commit;
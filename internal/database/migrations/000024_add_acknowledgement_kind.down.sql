@@ -0,0 +1,10 @@
+begin;
+
+-- acknowledgements of different alerts of the same merge request can't share the key without the kind
+delete from acknowledgements where kind <> '*';
+
+alter table acknowledgements drop constraint acknowledgements_pkey;
+alter table acknowledgements drop column kind;
+alter table acknowledgements add primary key (client_id, project_id, merge_request_iid, discussion_id);
+
+commit;
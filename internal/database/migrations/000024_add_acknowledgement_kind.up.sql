@@ -0,0 +1,12 @@
+begin;
+
+alter table acknowledgements add column kind varchar(50) not null default '';
+
+-- ignores apply to all alerts of the merge request, other acknowledgements can't be attributed to an alert
+update acknowledgements set kind = '*' where action = 'ignore';
+delete from acknowledgements where kind = '';
+
+alter table acknowledgements drop constraint acknowledgements_pkey;
+alter table acknowledgements add primary key (client_id, project_id, merge_request_iid, discussion_id, kind);
+
+commit;
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type NotificationLogRepository struct {
+	db *db
+}
+
+func NewNotificationLogRepository(db *db) *NotificationLogRepository {
+	return &NotificationLogRepository{db: db}
+}
+
+// Find returns the record with the same key as the given one or nil if there is no such record
+func (r *NotificationLogRepository) Find(key config.NotificationRecord) (*config.NotificationRecord, error) {
+	var records []*config.NotificationRecord
+	err := r.db.Select(&records, `
+			select * from notification_log
+			where client_id=$1 and kind=$2 and project_id=$3 and merge_request_iid=$4 and discussion_id=$5`,
+		key.ClientId, key.Kind, key.ProjectId, key.MergeRequestIid, key.DiscussionId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	return records[0], nil
+}
+
+func (r *NotificationLogRepository) Save(record *config.NotificationRecord) error {
+	_, err := r.db.NamedExec(`insert into
+			notification_log(
+				client_id,
+				kind,
+				project_id,
+				merge_request_iid,
+				discussion_id,
+				state,
+				notified_at
+			)
+			values (
+				:client_id,
+				:kind,
+				:project_id,
+				:merge_request_iid,
+				:discussion_id,
+				:state,
+				:notified_at
+			)
+			on conflict (client_id, kind, project_id, merge_request_iid, discussion_id) do update set
+				state=excluded.state,
+				notified_at=excluded.notified_at`,
+		record)
+
+	return err
+}
+
+// DeleteNotifiedBefore removes records of items which are not notified anymore e.g. merged merge requests
+func (r *NotificationLogRepository) DeleteNotifiedBefore(t time.Time) error {
+	_, err := r.db.Exec(`delete from notification_log where notified_at < $1`, t)
+	return err
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type NotificationPostRepository struct {
+	db *db
+}
+
+func NewNotificationPostRepository(db *db) *NotificationPostRepository {
+	return &NotificationPostRepository{db: db}
+}
+
+// Find returns the post with the same key as the given one or nil if there is no such post
+func (r *NotificationPostRepository) Find(key config.NotificationPost) (*config.NotificationPost, error) {
+	var posts []*config.NotificationPost
+	err := r.db.Select(&posts, `
+			select * from notification_posts
+			where client_id=$1 and target_id=$2 and kind=$3 and project_id=$4 and merge_request_iid=$5 and discussion_id=$6`,
+		key.ClientId, key.TargetId, key.Kind, key.ProjectId, key.MergeRequestIid, key.DiscussionId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, nil
+	}
+
+	return posts[0], nil
+}
+
+func (r *NotificationPostRepository) GetUnresolvedByClient(clientId int) ([]*config.NotificationPost, error) {
+	var posts []*config.NotificationPost
+	return posts, r.db.Select(&posts, `select * from notification_posts where client_id=$1 and not resolved`, clientId)
+}
+
+func (r *NotificationPostRepository) Save(post *config.NotificationPost) error {
+	_, err := r.db.NamedExec(`insert into
+			notification_posts(
+				client_id,
+				target_id,
+				kind,
+				project_id,
+				merge_request_iid,
+				discussion_id,
+				post_id,
+				text,
+				resolved,
+				created_at,
+				updated_at
+			)
+			values (
+				:client_id,
+				:target_id,
+				:kind,
+				:project_id,
+				:merge_request_iid,
+				:discussion_id,
+				:post_id,
+				:text,
+				:resolved,
+				:created_at,
+				:updated_at
+			)
+			on conflict (client_id, target_id, kind, project_id, merge_request_iid, discussion_id) do update set
+				post_id=excluded.post_id,
+				text=excluded.text,
+				resolved=excluded.resolved,
+				created_at=excluded.created_at,
+				updated_at=excluded.updated_at`,
+		post)
+
+	return err
+}
+
+// DeleteUpdatedBefore removes posts which are too old to update them
+func (r *NotificationPostRepository) DeleteUpdatedBefore(t time.Time) error {
+	_, err := r.db.Exec(`delete from notification_posts where updated_at < $1`, t)
+	return err
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type NotificationThreadRepository struct {
+	db *db
+}
+
+func NewNotificationThreadRepository(db *db) *NotificationThreadRepository {
+	return &NotificationThreadRepository{db: db}
+}
+
+// Find returns the thread with the same key as the given one or nil if there is no such thread
+func (r *NotificationThreadRepository) Find(key config.NotificationThread) (*config.NotificationThread, error) {
+	var threads []*config.NotificationThread
+	err := r.db.Select(&threads, `
+			select * from notification_threads
+			where client_id=$1 and target_id=$2 and project_id=$3 and merge_request_iid=$4`,
+		key.ClientId, key.TargetId, key.ProjectId, key.MergeRequestIid,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(threads) == 0 {
+		return nil, nil
+	}
+
+	return threads[0], nil
+}
+
+func (r *NotificationThreadRepository) Save(thread *config.NotificationThread) error {
+	_, err := r.db.NamedExec(`insert into
+			notification_threads(
+				client_id,
+				target_id,
+				project_id,
+				merge_request_iid,
+				root_post_id,
+				created_at,
+				updated_at
+			)
+			values (
+				:client_id,
+				:target_id,
+				:project_id,
+				:merge_request_iid,
+				:root_post_id,
+				:created_at,
+				:updated_at
+			)
+			on conflict (client_id, target_id, project_id, merge_request_iid) do update set
+				root_post_id=excluded.root_post_id,
+				created_at=excluded.created_at,
+				updated_at=excluded.updated_at`,
+		thread)
+
+	return err
+}
+
+// DeleteUpdatedBefore removes threads of merge requests which are not notified anymore
+func (r *NotificationThreadRepository) DeleteUpdatedBefore(t time.Time) error {
+	_, err := r.db.Exec(`delete from notification_threads where updated_at < $1`, t)
+	return err
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type OutboxRepository struct {
+	db *db
+}
+
+func NewOutboxRepository(db *db) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Create(entry *config.OutboxEntry) error {
+	entry.CreatedAt = time.Now().UTC()
+	entry.UpdatedAt = entry.CreatedAt
+
+	_, err := r.db.NamedExec(`insert into
+			outbox(
+				client_id,
+				target_id,
+				payload,
+				status,
+				attempts,
+				last_error,
+				next_attempt_at,
+				created_at,
+				updated_at
+			)
+			values (
+				:client_id,
+				:target_id,
+				:payload,
+				:status,
+				:attempts,
+				:last_error,
+				:next_attempt_at,
+				:created_at,
+				:updated_at
+			)`,
+		entry)
+
+	return err
+}
+
+// ClaimDue returns pending entries due at the time postponing them by the lease
+// so that concurrent workers skip them while they are being delivered
+func (r *OutboxRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*config.OutboxEntry, error) {
+	entries := make([]*config.OutboxEntry, 0)
+	err := r.db.Select(&entries, `
+			update outbox set next_attempt_at=$2
+			where id in (
+				select id from outbox
+				where status=$3 and next_attempt_at <= $1
+				order by next_attempt_at, id
+				limit $4
+				for update skip locked
+			)
+			returning *`,
+		now, now.Add(lease), config.OutboxStatusPending, limit,
+	)
+	return entries, err
+}
+
+func (r *OutboxRepository) Update(entry *config.OutboxEntry) error {
+	entry.UpdatedAt = time.Now().UTC()
+
+	res, err := r.db.NamedExec(`
+			update outbox set
+				status=:status,
+				attempts=:attempts,
+				last_error=:last_error,
+				next_attempt_at=:next_attempt_at,
+				updated_at=:updated_at
+			where id=:id`,
+		entry)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetAllFailed returns dead-lettered entries starting from the latest ones
+func (r *OutboxRepository) GetAllFailed() ([]*config.OutboxEntry, error) {
+	entries := make([]*config.OutboxEntry, 0)
+	return entries, r.db.Select(&entries, `select * from outbox where status=$1 order by updated_at desc, id desc`, config.OutboxStatusFailed)
+}
+
+// Replay makes the failed entry pending again with a fresh set of attempts
+func (r *OutboxRepository) Replay(id int) error {
+	now := time.Now().UTC()
+	res, err := r.db.Exec(`
+			update outbox set status=$1, attempts=0, next_attempt_at=$2, updated_at=$2
+			where id=$3 and status=$4`,
+		config.OutboxStatusPending, now, id, config.OutboxStatusFailed,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ReplayAllFailed makes all failed entries pending again and returns how many of them there were
+func (r *OutboxRepository) ReplayAllFailed() (int64, error) {
+	now := time.Now().UTC()
+	res, err := r.db.Exec(`
+			update outbox set status=$1, attempts=0, next_attempt_at=$2, updated_at=$2
+			where status=$3`,
+		config.OutboxStatusPending, now, config.OutboxStatusFailed,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteFinishedBefore removes delivered and failed entries which were not touched since the time
+func (r *OutboxRepository) DeleteFinishedBefore(t time.Time) error {
+	_, err := r.db.Exec(`delete from outbox where status<>$1 and updated_at < $2`, config.OutboxStatusPending, t)
+	return err
+}
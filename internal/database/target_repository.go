@@ -0,0 +1,102 @@
+package database
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type TargetRepository struct {
+	db *db
+}
+
+func NewTargetRepository(db *db) *TargetRepository {
+	return &TargetRepository{db: db}
+}
+
+func (r *TargetRepository) Get(clientId int, id int) (*config.Target, error) {
+	var targets []*config.Target
+	err := r.db.Select(&targets, `select * from client_targets where client_id=$1 and id=$2`, clientId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return targets[0], nil
+}
+
+func (r *TargetRepository) GetAllByClient(clientId int) ([]*config.Target, error) {
+	targets := make([]*config.Target, 0)
+	return targets, r.db.Select(&targets, `select * from client_targets where client_id=$1 order by id`, clientId)
+}
+
+func (r *TargetRepository) Create(target *config.Target) error {
+	target.CreatedAt = time.Now()
+	target.UpdatedAt = time.Now()
+
+	_, err := r.db.NamedExec(`insert into
+			client_targets(
+				client_id,
+				name,
+				webhook_type,
+				webhook_url,
+				webhook_token,
+				webhook_channel,
+				webhook_secret,
+				kinds,
+				project_ids,
+				created_at,
+				updated_at
+			)
+			values (
+				:client_id,
+				:name,
+				:webhook_type,
+				:webhook_url,
+				:webhook_token,
+				:webhook_channel,
+				:webhook_secret,
+				:kinds,
+				:project_ids,
+				:created_at,
+				:updated_at
+			)`,
+		target)
+
+	return err
+}
+
+func (r *TargetRepository) Update(target *config.Target) error {
+	target.UpdatedAt = time.Now()
+
+	res, err := r.db.NamedExec(`
+			update client_targets set
+				name=:name,
+				webhook_type=:webhook_type,
+				webhook_url=:webhook_url,
+				webhook_token=:webhook_token,
+				webhook_channel=:webhook_channel,
+				webhook_secret=:webhook_secret,
+				kinds=:kinds,
+				project_ids=:project_ids,
+				updated_at=:updated_at
+			where id=:id and client_id=:client_id`,
+		target)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TargetRepository) Delete(clientId int, id int) error {
+	_, err := r.db.Exec(`delete from client_targets where client_id=$1 and id=$2`, clientId, id)
+	return err
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+type UserMappingRepository struct {
+	db *db
+}
+
+func NewUserMappingRepository(db *db) *UserMappingRepository {
+	return &UserMappingRepository{db: db}
+}
+
+func (r *UserMappingRepository) Get(clientId int, id int) (*config.UserMapping, error) {
+	var mappings []*config.UserMapping
+	err := r.db.Select(&mappings, `select * from user_mappings where client_id=$1 and id=$2`, clientId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mappings) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return mappings[0], nil
+}
+
+func (r *UserMappingRepository) GetAllByClient(clientId int) ([]*config.UserMapping, error) {
+	mappings := make([]*config.UserMapping, 0)
+	return mappings, r.db.Select(&mappings, `select * from user_mappings where client_id=$1 order by gitlab_username`, clientId)
+}
+
+func (r *UserMappingRepository) Create(mapping *config.UserMapping) error {
+	mapping.CreatedAt = time.Now()
+	mapping.UpdatedAt = time.Now()
+
+	_, err := r.db.NamedExec(`insert into
+			user_mappings(
+				client_id,
+				gitlab_user_id,
+				gitlab_username,
+				chat_username,
+				chat_user_id,
+				created_at,
+				updated_at
+			)
+			values (
+				:client_id,
+				:gitlab_user_id,
+				:gitlab_username,
+				:chat_username,
+				:chat_user_id,
+				:created_at,
+				:updated_at
+			)`,
+		mapping)
+
+	return conflictError(err)
+}
+
+func (r *UserMappingRepository) Update(mapping *config.UserMapping) error {
+	mapping.UpdatedAt = time.Now()
+
+	res, err := r.db.NamedExec(`
+			update user_mappings set
+				gitlab_user_id=:gitlab_user_id,
+				gitlab_username=:gitlab_username,
+				chat_username=:chat_username,
+				chat_user_id=:chat_user_id,
+				updated_at=:updated_at
+			where id=:id and client_id=:client_id`,
+		mapping)
+	if err != nil {
+		return conflictError(err)
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Upsert creates or updates mappings by gitlab username in a single transaction
+func (r *UserMappingRepository) Upsert(mappings []*config.UserMapping) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %v", err)
+	}
+
+	for _, mapping := range mappings {
+		mapping.CreatedAt = time.Now()
+		mapping.UpdatedAt = time.Now()
+
+		_, err := tx.NamedExec(`insert into
+				user_mappings(
+					client_id,
+					gitlab_user_id,
+					gitlab_username,
+					chat_username,
+					chat_user_id,
+					created_at,
+					updated_at
+				)
+				values (
+					:client_id,
+					:gitlab_user_id,
+					:gitlab_username,
+					:chat_username,
+					:chat_user_id,
+					:created_at,
+					:updated_at
+				)
+				on conflict (client_id, gitlab_username) do update set
+					gitlab_user_id=excluded.gitlab_user_id,
+					chat_username=excluded.chat_username,
+					chat_user_id=excluded.chat_user_id,
+					updated_at=excluded.updated_at`,
+			mapping)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("upsert mapping of %s: %v", mapping.GitlabUsername, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *UserMappingRepository) Delete(clientId int, id int) error {
+	_, err := r.db.Exec(`delete from user_mappings where client_id=$1 and id=$2`, clientId, id)
+	return err
+}
@@ -1,18 +1,36 @@
 package internal
 
 const (
-	EnvDbUrl                    = "DB_URL"
-	EnvDbHost                   = "DB_HOST"
-	EnvDbPort                   = "DB_PORT"
-	EnvDbUser                   = "DB_USER"
-	EnvDbPassword               = "DB_PASSWORD"
-	EnvDbName                   = "DB_NAME"
-	EnvGitlabUrl                = "GITLAB_URL"
-	EnvLogLevel                 = "LOG_LEVEL"
-	EnvLogMode                  = "LOG_MODE"
-	EnvTimeZone                 = "TIME_ZONE"
-	EnvWorkdayStartsAt          = "WORKDAY_START_AT_HOUR"
-	EnvWorkdayEndsAt            = "WORKDAY_END_AT_HOUR"
-	EnvSchedulerIntervalMinutes = "SCHEDULER_INTERVAL_MINUTES"
-	EnvSchedulerFixedTimes      = "SCHEDULER_FIXED_TIMES"
+	EnvDbUrl                        = "DB_URL"
+	EnvDbHost                       = "DB_HOST"
+	EnvDbPort                       = "DB_PORT"
+	EnvDbUser                       = "DB_USER"
+	EnvDbPassword                   = "DB_PASSWORD"
+	EnvDbName                       = "DB_NAME"
+	EnvGitlabUrl                    = "GITLAB_URL"
+	EnvLogLevel                     = "LOG_LEVEL"
+	EnvLogMode                      = "LOG_MODE"
+	EnvTimeZone                     = "TIME_ZONE"
+	EnvWorkdayStartsAt              = "WORKDAY_START_AT_HOUR"
+	EnvWorkdayEndsAt                = "WORKDAY_END_AT_HOUR"
+	EnvSchedulerIntervalMinutes     = "SCHEDULER_INTERVAL_MINUTES"
+	EnvSchedulerFixedTimes          = "SCHEDULER_FIXED_TIMES"
+	EnvSmtpHost                     = "SMTP_HOST"
+	EnvSmtpPort                     = "SMTP_PORT"
+	EnvSmtpUsername                 = "SMTP_USERNAME"
+	EnvSmtpPassword                 = "SMTP_PASSWORD"
+	EnvSmtpFrom                     = "SMTP_FROM"
+	EnvSmtpSecurity                 = "SMTP_SECURITY"
+	EnvOutboxIntervalSeconds        = "OUTBOX_INTERVAL_SECONDS"
+	EnvOutboxMaxAttempts            = "OUTBOX_MAX_ATTEMPTS"
+	EnvCallbackUrl                  = "CALLBACK_URL"
+	EnvCallbackSecret               = "CALLBACK_SECRET"
+	EnvSlackSigningSecret           = "SLACK_SIGNING_SECRET"
+	EnvGitlabHookDebounceSeconds    = "GITLAB_HOOK_DEBOUNCE_SECONDS"
+	EnvGitlabConcurrency            = "GITLAB_CONCURRENCY"
+	EnvClientConcurrency            = "CLIENT_CONCURRENCY"
+	EnvClientTimeoutMinutes         = "CLIENT_TIMEOUT_MINUTES"
+	EnvGitlabCacheStorage           = "GITLAB_CACHE_STORAGE"
+	EnvGitlabCacheMemoryMegabytes   = "GITLAB_CACHE_MEMORY_MB"
+	EnvGitlabDiscussionCacheMinutes = "GITLAB_DISCUSSION_CACHE_MINUTES"
 )
@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// AckActionIgnore is the action of acknowledgements silencing the whole merge request with all its discussions,
+// acknowledgements with other actions silence only the alert they are made on
+const AckActionIgnore = "ignore"
+
+// AckKindAll is the kind of ignores which apply to alerts of all kinds,
+// it keeps them apart from acknowledgements of single alerts so that those never replace them
+const AckKindAll = "*"
+
+// Acknowledgement is a reaction of a chat user to an alert, alerts about the item are skipped until it expires
+type Acknowledgement struct {
+	ClientId        int `json:"client_id" db:"client_id"`
+	ProjectId       int `json:"project_id" db:"project_id"`
+	MergeRequestIid int `json:"merge_request_iid" db:"merge_request_iid"`
+	// DiscussionId is empty for acknowledgements of merge request alerts
+	DiscussionId string `json:"discussion_id" db:"discussion_id"`
+	// Kind is the notification kind of the alert or AckKindAll for ignores
+	Kind     string `json:"kind" db:"kind"`
+	Action   string `json:"action" db:"action"`
+	UserName string `json:"user_name" db:"user_name"`
+	// Until is nil for merge requests ignored forever
+	Until     *time.Time `json:"until" db:"until"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// ClientRun is the outcome of the last processing of a client
+type ClientRun struct {
+	ClientId int `json:"client_id" db:"client_id"`
+	// Degraded tells that some merge requests were skipped as gitlab failed to return them
+	Degraded bool `json:"degraded" db:"degraded"`
+	// Error is empty if the run checked everything
+	Error      string    `json:"error" db:"error"`
+	FinishedAt time.Time `json:"finished_at" db:"finished_at"`
+}
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// EscalationStep is a step of the old merge requests escalation policy of a client,
+// the last step whose timeout has passed since the merge request last update is applied
+type EscalationStep struct {
+	Id       int `json:"id" db:"id"`
+	ClientId int `json:"client_id" db:"client_id"`
+	// Position is the order of the step in the policy
+	Position int `json:"position" db:"position"`
+	// Timeout is the duration passed since the merge request last update to apply the step
+	Timeout string `json:"timeout" db:"timeout"`
+	// Mention is used in the notification instead of merge_request_old_mention
+	Mention string `json:"mention" db:"mention"`
+	// TargetId is the id of the target to send the notification to or 0 to route it as usual
+	TargetId  int       `json:"target_id" db:"target_id"`
+	Color     string    `json:"color" db:"color"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
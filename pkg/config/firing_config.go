@@ -4,17 +4,69 @@ import (
 	"time"
 )
 
+const (
+	// DeliveryModeChannel sends notifications to the channel of the webhook
+	DeliveryModeChannel = "channel"
+	// DeliveryModeDirect sends notifications directly to responsible users if webhook supports it
+	DeliveryModeDirect = "direct"
+)
+
+const (
+	// RepeatModePost sends a new post on each notification about the same item
+	RepeatModePost = "post"
+	// RepeatModeUpdate updates the last post about the item if webhook supports it
+	RepeatModeUpdate = "update"
+	// RepeatModeThread replies in the thread of the first post about the merge request if webhook supports it
+	RepeatModeThread = "thread"
+)
+
+const (
+	// ReviewModeParticipants counts participants of merge requests as reviewers
+	ReviewModeParticipants = "participants"
+	// ReviewModeApprovals checks approvals of merge requests against their approval rules,
+	// merge_request_reviewers_count is ignored and merge requests requiring no approvals never lack review
+	ReviewModeApprovals = "approvals"
+)
+
 type FiringConfig struct {
 	Id                         int       `json:"id" db:"id"`
 	GroupId                    int       `json:"group_id" db:"group_id"`
 	GitlabToken                string    `json:"gitlab_token" db:"gitlab_token"`
+	WebhookType                string    `json:"webhook_type" db:"webhook_type"`
 	WebhookUrl                 string    `json:"webhook_url" db:"webhook_url"`
+	WebhookToken               string    `json:"webhook_token" db:"webhook_token"`
+	WebhookChannel             string    `json:"webhook_channel" db:"webhook_channel"`
+	WebhookSecret              string    `json:"webhook_secret" db:"webhook_secret"`
+	DeliveryMode               string    `json:"delivery_mode" db:"delivery_mode"`
+	RepeatMode                 string    `json:"repeat_mode" db:"repeat_mode"`
 	DiscussionFiringTimeout    string    `json:"discussion_firing_timeout" db:"discussion_firing_timeout"`
 	MergeRequestOldTimeout     string    `json:"merge_request_old_timeout" db:"merge_request_old_timeout"`
 	MergeRequestOldMention     string    `json:"merge_request_old_mention" db:"merge_request_old_mention"`
 	MergeRequestReviewTimeout  string    `json:"merge_request_review_timeout" db:"merge_request_review_timeout"`
 	MergeRequestReviewersCount int       `json:"merge_request_reviewers_count" db:"merge_request_reviewers_count"`
 	MergeRequestReviewMention  string    `json:"merge_request_review_mention" db:"merge_request_review_mention"`
+	MergeRequestReviewMode     string    `json:"merge_request_review_mode" db:"merge_request_review_mode"`
+	RealertInterval            string    `json:"realert_interval" db:"realert_interval"`
+	DigestMode                 bool      `json:"digest_mode" db:"digest_mode"`
+	CommandToken               string    `json:"command_token" db:"command_token"`
+	GitlabHookToken            string    `json:"gitlab_hook_token" db:"gitlab_hook_token"`
+	MergeRequestsLimit         int       `json:"merge_requests_limit" db:"merge_requests_limit"`
 	CreatedAt                  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt                  time.Time `json:"updated_at" db:"updated_at"`
 }
+
+func (c FiringConfig) IsDirectDelivery() bool {
+	return c.DeliveryMode == DeliveryModeDirect
+}
+
+func (c FiringConfig) IsUpdateRepeat() bool {
+	return c.RepeatMode == RepeatModeUpdate
+}
+
+func (c FiringConfig) IsThreadRepeat() bool {
+	return c.RepeatMode == RepeatModeThread
+}
+
+func (c FiringConfig) IsApprovalsReview() bool {
+	return c.MergeRequestReviewMode == ReviewModeApprovals
+}
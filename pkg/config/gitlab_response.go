@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+// GitlabResponse is a response of gitlab API kept to revalidate it by its ETag
+type GitlabResponse struct {
+	// Key is a hash of the request url and the token it was made with
+	Key  string `json:"key" db:"key"`
+	ETag string `json:"etag" db:"etag"`
+	// Header is the response header serialized to JSON, it keeps pagination headers of cached pages
+	Header    string    `json:"header" db:"header"`
+	Body      []byte    `json:"body" db:"body"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
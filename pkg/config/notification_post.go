@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// NotificationPost is the chat post of the last notification about a merge request or a discussion
+// which is updated on next notifications instead of sending new posts
+type NotificationPost struct {
+	ClientId int `json:"client_id" db:"client_id"`
+	// TargetId is the id of the target the post is sent to or 0 for the webhook of the client
+	TargetId        int    `json:"target_id" db:"target_id"`
+	Kind            string `json:"kind" db:"kind"`
+	ProjectId       int    `json:"project_id" db:"project_id"`
+	MergeRequestIid int    `json:"merge_request_iid" db:"merge_request_iid"`
+	// DiscussionId is empty for merge request notification kinds
+	DiscussionId string `json:"discussion_id" db:"discussion_id"`
+	PostId       string `json:"post_id" db:"post_id"`
+	// Text is the last text of the post, it is struck through once the item is resolved
+	Text string `json:"text" db:"text"`
+	// Resolved is set when the merge request is merged or closed or the discussion is resolved
+	Resolved  bool      `json:"resolved" db:"resolved"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
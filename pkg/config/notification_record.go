@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// NotificationRecord is the last notification sent about a merge request or a discussion
+type NotificationRecord struct {
+	ClientId        int    `json:"client_id" db:"client_id"`
+	Kind            string `json:"kind" db:"kind"`
+	ProjectId       int    `json:"project_id" db:"project_id"`
+	MergeRequestIid int    `json:"merge_request_iid" db:"merge_request_iid"`
+	// DiscussionId is empty for merge request notification kinds
+	DiscussionId string `json:"discussion_id" db:"discussion_id"`
+	// State is a digest of the notified item state, the item is notified again once it changes
+	State      string    `json:"state" db:"state"`
+	NotifiedAt time.Time `json:"notified_at" db:"notified_at"`
+}
@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+// NotificationThread is the first post about a merge request later notifications about it are replied to
+type NotificationThread struct {
+	ClientId int `json:"client_id" db:"client_id"`
+	// TargetId is the id of the target the post is sent to or 0 for the webhook of the client
+	TargetId        int       `json:"target_id" db:"target_id"`
+	ProjectId       int       `json:"project_id" db:"project_id"`
+	MergeRequestIid int       `json:"merge_request_iid" db:"merge_request_iid"`
+	RootPostId      string    `json:"root_post_id" db:"root_post_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
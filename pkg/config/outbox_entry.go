@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	// OutboxStatusFailed marks dead-lettered entries whose attempts are exhausted or which the webhook rejected
+	OutboxStatusFailed = "failed"
+)
+
+// OutboxEntry is a rendered notification waiting to be delivered to the webhook of a client or its target
+type OutboxEntry struct {
+	Id       int `json:"id" db:"id"`
+	ClientId int `json:"client_id" db:"client_id"`
+	// TargetId is the id of the target the notification is sent to or 0 for the webhook of the client
+	TargetId int `json:"target_id" db:"target_id"`
+	// Payload is the webhook delivery serialized to JSON
+	Payload       string    `json:"payload" db:"payload"`
+	Status        string    `json:"status" db:"status"`
+	Attempts      int       `json:"attempts" db:"attempts"`
+	LastError     string    `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
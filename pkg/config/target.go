@@ -0,0 +1,97 @@
+package config
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification kinds a target can receive
+const (
+	KindOldMergeRequest          = "old_merge_request"
+	KindNeededReviewMergeRequest = "needed_review_merge_request"
+	KindFiringDiscussion         = "firing_discussion"
+)
+
+// Target is an additional notification destination of a client
+// receiving only notifications of the listed kinds about the listed projects
+type Target struct {
+	Id             int        `json:"id" db:"id"`
+	ClientId       int        `json:"client_id" db:"client_id"`
+	Name           string     `json:"name" db:"name"`
+	WebhookType    string     `json:"webhook_type" db:"webhook_type"`
+	WebhookUrl     string     `json:"webhook_url" db:"webhook_url"`
+	WebhookToken   string     `json:"webhook_token" db:"webhook_token"`
+	WebhookChannel string     `json:"webhook_channel" db:"webhook_channel"`
+	WebhookSecret  string     `json:"webhook_secret" db:"webhook_secret"`
+	Kinds          StringList `json:"kinds" db:"kinds"`
+	ProjectIds     IntList    `json:"project_ids" db:"project_ids"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// StringList is stored in DB as a comma separated string
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+func (l *StringList) Scan(src interface{}) error {
+	str, err := scanString(src)
+	if err != nil {
+		return err
+	}
+	*l = StringList{}
+	for _, item := range strings.Split(str, ",") {
+		if len(item) > 0 {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// IntList is stored in DB as a comma separated string
+type IntList []int
+
+func (l IntList) Value() (driver.Value, error) {
+	items := make([]string, 0, len(l))
+	for _, item := range l {
+		items = append(items, strconv.Itoa(item))
+	}
+	return strings.Join(items, ","), nil
+}
+
+func (l *IntList) Scan(src interface{}) error {
+	str, err := scanString(src)
+	if err != nil {
+		return err
+	}
+	*l = IntList{}
+	for _, item := range strings.Split(str, ",") {
+		if len(item) == 0 {
+			continue
+		}
+		val, err := strconv.Atoi(item)
+		if err != nil {
+			return fmt.Errorf("parse int list item %s: %v", item, err)
+		}
+		*l = append(*l, val)
+	}
+	return nil
+}
+
+func scanString(src interface{}) (string, error) {
+	switch val := src.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	default:
+		return "", fmt.Errorf("unsupported type %T of list", src)
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// UserMapping maps a gitlab user to a chat user of a client
+type UserMapping struct {
+	Id             int    `json:"id" db:"id"`
+	ClientId       int    `json:"client_id" db:"client_id"`
+	GitlabUserId   int    `json:"gitlab_user_id" db:"gitlab_user_id"`
+	GitlabUsername string `json:"gitlab_username" db:"gitlab_username"`
+	// ChatUsername is a handle used in mentions
+	ChatUsername string `json:"chat_username" db:"chat_username"`
+	// ChatUserId is an id of the user in chat API used to send direct messages, optional
+	ChatUserId string    `json:"chat_user_id" db:"chat_user_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package firingservice
+
+import (
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+// AckStore stores acknowledgements and snoozes of alerts made by chat users with alert buttons
+type AckStore interface {
+	// FindActive returns the acknowledgement of the alert of the kind about the item
+	// or the ignore of its whole merge request which hasn't expired at the time
+	// or nil if there is no such acknowledgement
+	FindActive(key config.Acknowledgement, now time.Time) (*config.Acknowledgement, error)
+}
+
+// isAcknowledged reports whether somebody is on the item or snoozed or ignored its alerts
+func (service *FiringService) isAcknowledged(record *config.NotificationRecord) bool {
+	if service.acks == nil {
+		return false
+	}
+
+	key := config.Acknowledgement{
+		ClientId:        record.ClientId,
+		ProjectId:       record.ProjectId,
+		MergeRequestIid: record.MergeRequestIid,
+		DiscussionId:    record.DiscussionId,
+		Kind:            record.Kind,
+	}
+	ack, err := service.acks.FindActive(key, time.Now().UTC())
+	if err != nil {
+		service.Log().Warnf("Failed to find acknowledgement of merge request %d in project %d: %v", record.MergeRequestIid, record.ProjectId, err)
+		return false
+	}
+
+	return ack != nil
+}
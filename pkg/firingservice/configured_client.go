@@ -3,39 +3,123 @@ package firingservice
 import (
 	"gitlab-code-review-notifier/pkg/config"
 	"gitlab-code-review-notifier/pkg/gitlabservice"
+	"gitlab-code-review-notifier/pkg/interaction"
+	"gitlab-code-review-notifier/pkg/log"
 	"gitlab-code-review-notifier/pkg/notifier"
 	"gitlab-code-review-notifier/pkg/webhook"
 )
 
+// defaultColor highlights notifications unless an escalation step sets another color
+const defaultColor = "#ff0000"
+
 type ConfiguredClient struct {
 	Client   *gitlabservice.Client
 	Notifier *notifier.Notifier
 	Config   config.FiringConfig
+	// EscalationSteps are ordered steps of the old merge requests escalation policy
+	EscalationSteps []*config.EscalationStep
 }
 
 type ConfiguredClientFactory struct {
 	gitlabClientFactory *gitlabservice.ClientFactory
 	notifierFactory     *notifier.Factory
+	webhookRegistry     *webhook.Registry
+	postStore           notifier.PostStore
+	threadStore         notifier.ThreadStore
+	outbox              notifier.Outbox
+	buttons             *interaction.Buttons
+	log.Loggable
 }
 
-func NewConfiguredClientFactory(gitlabClientFactory *gitlabservice.ClientFactory, notifierFactory *notifier.Factory) *ConfiguredClientFactory {
-	return &ConfiguredClientFactory{gitlabClientFactory: gitlabClientFactory, notifierFactory: notifierFactory}
+func NewConfiguredClientFactory(
+	gitlabClientFactory *gitlabservice.ClientFactory,
+	notifierFactory *notifier.Factory,
+	webhookRegistry *webhook.Registry,
+	postStore notifier.PostStore,
+	threadStore notifier.ThreadStore,
+	outbox notifier.Outbox,
+	buttons *interaction.Buttons,
+) *ConfiguredClientFactory {
+	return &ConfiguredClientFactory{
+		gitlabClientFactory: gitlabClientFactory,
+		notifierFactory:     notifierFactory,
+		webhookRegistry:     webhookRegistry,
+		postStore:           postStore,
+		threadStore:         threadStore,
+		outbox:              outbox,
+		buttons:             buttons,
+	}
 }
 
-func (f *ConfiguredClientFactory) MakeClient(config config.FiringConfig) (*ConfiguredClient, error) {
-	gitlabClient, err := f.gitlabClientFactory.MakeClient(config.GitlabToken)
+func (f *ConfiguredClientFactory) MakeClient(
+	config config.FiringConfig,
+	targets []*config.Target,
+	userMappings []*config.UserMapping,
+	escalationSteps []*config.EscalationStep,
+) (*ConfiguredClient, error) {
+	gitlabClient, err := f.gitlabClientFactory.MakeClient(config.GitlabToken, config.MergeRequestsLimit)
+	if err != nil {
+		return nil, err
+	}
+	hook, err := f.webhookRegistry.Make(config.WebhookType, clientWebhookSettings(config))
 	if err != nil {
 		return nil, err
 	}
+	routes := make([]notifier.Route, 0, len(targets))
+	for _, target := range targets {
+		// a broken target must not silence the client and its other targets
+		targetHook, err := f.webhookRegistry.Make(target.WebhookType, TargetWebhookSettings(target))
+		if err != nil {
+			f.Log().Errorf("Failed to make webhook of target %d of client %d, skipping its route: %v", target.Id, config.Id, err)
+			continue
+		}
+		routes = append(routes, notifier.Route{
+			TargetId:   target.Id,
+			Name:       target.Name,
+			Webhook:    targetHook,
+			Kinds:      target.Kinds,
+			ProjectIds: target.ProjectIds,
+		})
+	}
+	notifierOptions := notifier.Options{
+		Routes:         routes,
+		Emails:         gitlabClient.Users(),
+		DirectMessages: config.IsDirectDelivery(),
+		UserMappings:   userMappings,
+		ClientId:       config.Id,
+		Outbox:         f.outbox,
+		Buttons:        f.buttons,
+	}
+	if config.IsUpdateRepeat() {
+		notifierOptions.Posts = f.postStore
+	}
+	if config.IsThreadRepeat() {
+		notifierOptions.Threads = f.threadStore
+	}
 	return &ConfiguredClient{
-		Client: gitlabClient,
-		Notifier: f.notifierFactory.MakeWebhookNotifier(webhook.MattermostConfig{
-			WebhookUrl:   config.WebhookUrl,
-			Channel:      "",
-			Username:     "",
-			IconUrl:      "",
-			DefaultColor: "#ff0000",
-		}),
-		Config: config,
+		Client:          gitlabClient,
+		Notifier:        f.notifierFactory.MakeWebhookNotifier(hook, notifierOptions),
+		Config:          config,
+		EscalationSteps: escalationSteps,
 	}, nil
 }
+
+func clientWebhookSettings(config config.FiringConfig) webhook.Settings {
+	return webhook.Settings{
+		Url:          config.WebhookUrl,
+		Token:        config.WebhookToken,
+		Channel:      config.WebhookChannel,
+		Secret:       config.WebhookSecret,
+		DefaultColor: defaultColor,
+	}
+}
+
+func TargetWebhookSettings(target *config.Target) webhook.Settings {
+	return webhook.Settings{
+		Url:          target.WebhookUrl,
+		Token:        target.WebhookToken,
+		Channel:      target.WebhookChannel,
+		Secret:       target.WebhookSecret,
+		DefaultColor: defaultColor,
+	}
+}
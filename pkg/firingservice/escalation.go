@@ -0,0 +1,43 @@
+package firingservice
+
+import (
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+// findEscalationStep returns the last step of the client escalation policy
+// whose timeout has passed since the merge request last update or nil if there is no such step
+func findEscalationStep(client *ConfiguredClient, mr *gitlab.MergeRequest) *config.EscalationStep {
+	var found *config.EscalationStep
+	for _, step := range client.EscalationSteps {
+		timeout, err := time.ParseDuration(step.Timeout)
+		if err != nil {
+			continue
+		}
+		// it is assumed that go-gitlab package returns timestamps in UTC
+		if time.Now().UTC().After(mr.UpdatedAt.Add(timeout)) {
+			found = step
+		}
+	}
+	return found
+}
+
+// minEscalationTimeout returns the timeout of the first step of the client escalation policy to be applied
+func minEscalationTimeout(client *ConfiguredClient) (time.Duration, bool) {
+	var min time.Duration
+	found := false
+	for _, step := range client.EscalationSteps {
+		timeout, err := time.ParseDuration(step.Timeout)
+		if err != nil {
+			continue
+		}
+		if !found || timeout < min {
+			min = timeout
+			found = true
+		}
+	}
+	return min, found
+}
@@ -0,0 +1,106 @@
+package firingservice
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/gitlabservice"
+)
+
+// NotificationLog stores last notifications to not repeat them on each run
+type NotificationLog interface {
+	// Find returns the record with the same key as the given one or nil if the item was never notified
+	Find(key config.NotificationRecord) (*config.NotificationRecord, error)
+	Save(record *config.NotificationRecord) error
+}
+
+// shouldNotify reports whether the item is not acknowledged and was never notified, its state has changed
+// or the re-alert interval of the client has passed since the last notification
+func (service *FiringService) shouldNotify(client *ConfiguredClient, record *config.NotificationRecord) bool {
+	if service.isAcknowledged(record) {
+		return false
+	}
+
+	if service.notificationLog == nil || len(client.Config.RealertInterval) == 0 {
+		return true
+	}
+
+	realertInterval, err := time.ParseDuration(client.Config.RealertInterval)
+	if err != nil {
+		service.Log().Errorf("Failed to parse duration from %s: %v", client.Config.RealertInterval, err)
+		return true
+	}
+
+	last, err := service.notificationLog.Find(*record)
+	if err != nil {
+		service.Log().Warnf("Failed to find last %s notification of merge request %d in project %d: %v", record.Kind, record.MergeRequestIid, record.ProjectId, err)
+		return true
+	}
+
+	return last == nil ||
+		last.State != record.State ||
+		record.NotifiedAt.After(last.NotifiedAt.Add(realertInterval))
+}
+
+func (service *FiringService) markNotified(record *config.NotificationRecord) {
+	if service.notificationLog == nil {
+		return
+	}
+	if err := service.notificationLog.Save(record); err != nil {
+		service.Log().Warnf("Failed to save %s notification of merge request %d in project %d: %v", record.Kind, record.MergeRequestIid, record.ProjectId, err)
+	}
+}
+
+// makeOldMergeRequestRecord makes a record which state changes on escalation to the next step as well
+func makeOldMergeRequestRecord(client *ConfiguredClient, mr *gitlab.MergeRequest, step *config.EscalationStep) *config.NotificationRecord {
+	if step != nil {
+		return makeRecord(client, config.KindOldMergeRequest, mr, "", mr.UpdatedAt.Unix(), mr.UserNotesCount, step.Id)
+	}
+	return makeRecord(client, config.KindOldMergeRequest, mr, "", mr.UpdatedAt.Unix(), mr.UserNotesCount)
+}
+
+// makeNeededReviewRecord changes with approvers in approvals review mode and with participants otherwise
+func makeNeededReviewRecord(client *ConfiguredClient, mrp *gitlabservice.MergeRequestWithParticipants) *config.NotificationRecord {
+	if mrp.Approval != nil {
+		return makeRecord(client, config.KindNeededReviewMergeRequest, mrp.MergeRequest, "", mrp.MergeRequest.Upvotes, userIds(mrp.ApprovedBy))
+	}
+	return makeRecord(client, config.KindNeededReviewMergeRequest, mrp.MergeRequest, "", mrp.MergeRequest.Upvotes, userIds(mrp.Participants))
+}
+
+func makeFiringDiscussionRecord(client *ConfiguredClient, mr *gitlab.MergeRequest, discussion *gitlab.Discussion) *config.NotificationRecord {
+	participants := gitlabservice.GetDiscussionParticipants(*mr, *discussion)
+	users := make([]*gitlab.BasicUser, 0, len(participants))
+	for i := range participants {
+		users = append(users, &participants[i])
+	}
+	lastNote := gitlabservice.GetLastNoteInDiscussion(discussion)
+	return makeRecord(client, config.KindFiringDiscussion, mr, discussion.ID, lastNote.ID, userIds(users))
+}
+
+func makeRecord(client *ConfiguredClient, kind string, mr *gitlab.MergeRequest, discussionId string, state ...interface{}) *config.NotificationRecord {
+	digest := sha1.Sum([]byte(fmt.Sprint(state...)))
+	return &config.NotificationRecord{
+		ClientId:        client.Config.Id,
+		Kind:            kind,
+		ProjectId:       mr.ProjectID,
+		MergeRequestIid: mr.IID,
+		DiscussionId:    discussionId,
+		State:           hex.EncodeToString(digest[:]),
+		NotifiedAt:      time.Now().UTC(),
+	}
+}
+
+func userIds(users []*gitlab.BasicUser) []int {
+	ids := make([]int, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,87 @@
+package firingservice
+
+import (
+	"context"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/gitlabservice"
+	"gitlab-code-review-notifier/pkg/notifier"
+)
+
+// ReviewStatus collects merge requests of the group the gitlab user has to review,
+// the user's own ones waiting for others and stale ones
+func (service *FiringService) ReviewStatus(ctx context.Context, client *ConfiguredClient, username string) notifier.ReviewStatus {
+	status := notifier.ReviewStatus{Username: username}
+	snapshot := client.Client.Snapshot(ctx, client.Config.GroupId)
+
+	waitingDiscussions := make(map[int][]gitlab.Discussion)
+	for _, fmr := range service.findFiringGroupMergeRequests(ctx, client, snapshot) {
+		waitingDiscussions[fmr.MergeRequest.ID] = fmr.FiringDiscussions
+	}
+
+	for _, mr := range snapshot.MergeRequests {
+		if mr.WorkInProgress {
+			continue
+		}
+		discussions := waitingDiscussions[mr.ID]
+		if mr.Author != nil && mr.Author.Username == username {
+			status.OwnMergeRequests = append(status.OwnMergeRequests, notifier.NewReviewStatusItem(mr, len(discussions)))
+			continue
+		}
+		userDiscussions := countParticipatedDiscussions(mr, discussions, username)
+		if isAssignee(mr, username) || userDiscussions > 0 {
+			status.PendingReviews = append(status.PendingReviews, notifier.NewReviewStatusItem(mr, userDiscussions))
+		}
+	}
+
+	for _, mr := range service.findOldOpenedGroupMergeRequests(client, snapshot) {
+		status.StaleMergeRequests = append(status.StaleMergeRequests, notifier.NewReviewStatusItem(mr, len(waitingDiscussions[mr.ID])))
+	}
+
+	status.Incomplete = len(snapshot.Errors()) > 0
+
+	return status
+}
+
+// ResolveGitlabUsername returns the gitlab username of the chat user according to user mappings of the client
+// or the chat username itself if the user is not mapped
+func ResolveGitlabUsername(mappings []*config.UserMapping, chatUserId string, chatUsername string) string {
+	for _, mapping := range mappings {
+		if len(mapping.ChatUserId) > 0 && mapping.ChatUserId == chatUserId {
+			return mapping.GitlabUsername
+		}
+	}
+	for _, mapping := range mappings {
+		if len(mapping.ChatUsername) > 0 && mapping.ChatUsername == chatUsername {
+			return mapping.GitlabUsername
+		}
+	}
+	return chatUsername
+}
+
+func isAssignee(mr *gitlab.MergeRequest, username string) bool {
+	if mr.Assignee != nil && mr.Assignee.Username == username {
+		return true
+	}
+	for _, assignee := range mr.Assignees {
+		if assignee != nil && assignee.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+func countParticipatedDiscussions(mr *gitlab.MergeRequest, discussions []gitlab.Discussion, username string) int {
+	count := 0
+	for _, discussion := range discussions {
+		for _, participant := range gitlabservice.GetDiscussionParticipants(*mr, discussion) {
+			if participant.Username == username {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
@@ -1,108 +1,445 @@
 package firingservice
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/gitlabservice"
 	"gitlab-code-review-notifier/pkg/log"
+	"gitlab-code-review-notifier/pkg/notifier"
+	"gitlab-code-review-notifier/pkg/workerpool"
 )
 
+// ErrDegraded marks runs which skipped merge requests gitlab failed to return
+var ErrDegraded = errors.New("run is degraded")
+
+// mergeRequestKey identifies a merge request across projects of a group
+type mergeRequestKey struct {
+	projectId       int
+	mergeRequestIid int
+}
+
 type FiringService struct {
+	notificationLog NotificationLog
+	acks            AckStore
 	log.Loggable
 }
 
-func NewFiringService() *FiringService {
-	return &FiringService{}
+func NewFiringService(notificationLog NotificationLog, acks AckStore) *FiringService {
+	return &FiringService{
+		notificationLog: notificationLog,
+		acks:            acks,
+	}
+}
+
+// ProcessAllConfigs processes at most concurrency clients at once
+func (service *FiringService) ProcessAllConfigs(ctx context.Context, clients []*ConfiguredClient, concurrency int) {
+	workerpool.Run(ctx, concurrency, len(clients), func(ctx context.Context, i int) {
+		if err := service.ProcessConfig(ctx, clients[i]); err != nil {
+			service.Log().Warnf("Failed to process client %d completely: %v", clients[i].Config.Id, err)
+		}
+	})
 }
 
-func (service *FiringService) ProcessAllConfigs(clients []*ConfiguredClient) {
-	for _, config := range clients {
-		service.ProcessConfig(config)
+// ProcessConfig runs all checks of the client against a single snapshot of its group,
+// returns ErrDegraded if some merge requests were skipped as gitlab failed to return them
+func (service *FiringService) ProcessConfig(ctx context.Context, client *ConfiguredClient) error {
+	snapshot := client.Client.Snapshot(ctx, client.Config.GroupId)
+	if client.Config.DigestMode {
+		service.ProcessDigest(ctx, client, snapshot)
+		return snapshotError(snapshot)
 	}
+	service.processChecks(ctx, client, snapshot, allChecks)
+	if client.Config.IsUpdateRepeat() {
+		service.ResolvePosts(ctx, client, snapshot)
+	}
+	return snapshotError(snapshot)
+}
+
+// Checks tells which checks are run against a merge request
+type Checks struct {
+	Discussions     bool
+	OldMergeRequest bool
+	NeededReview    bool
 }
 
-func (service *FiringService) ProcessConfig(client *ConfiguredClient) {
+// MergeRequestChecks is a merge request along with checks to run against it
+type MergeRequestChecks struct {
+	MergeRequest *gitlab.MergeRequest
+	Checks       Checks
+}
+
+// ProcessMergeRequests runs only the given checks of the client against the given merge requests,
+// merge requests whose state is merged or closed are not fetched and only get their posts resolved.
+// Merge requests of projects outside the group of the client are skipped as events may come from any project
+// of the gitlab webhook. Digests are about the whole group, so clients in digest mode are left to scheduled runs
+func (service *FiringService) ProcessMergeRequests(ctx context.Context, client *ConfiguredClient, mrChecks []MergeRequestChecks) error {
+	if client.Config.DigestMode {
+		return nil
+	}
+
+	projectIds, err := client.Client.Projects().GetGroupProjectIds(ctx, client.Config.GroupId)
+	if err != nil {
+		return err
+	}
+	mrs := make([]*gitlab.MergeRequest, 0, len(mrChecks))
+	checks := make(map[mergeRequestKey]Checks, len(mrChecks))
+	for _, mrCheck := range mrChecks {
+		mr := mrCheck.MergeRequest
+		if !projectIds[mr.ProjectID] {
+			service.Log().Debugf("Skipping merge request %d of project %d outside group %d", mr.IID, mr.ProjectID, client.Config.GroupId)
+			continue
+		}
+		mrs = append(mrs, mr)
+		checks[mergeRequestKey{mr.ProjectID, mr.IID}] = mrCheck.Checks
+	}
+
+	states := make(map[mergeRequestKey]string, len(mrs))
+	openedMrs := make([]*gitlab.MergeRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		if mr.State == "merged" || mr.State == "closed" {
+			states[mergeRequestKey{mr.ProjectID, mr.IID}] = mr.State
+			continue
+		}
+		openedMrs = append(openedMrs, mr)
+	}
+
+	snapshot := client.Client.MergeRequestsSnapshot(ctx, client.Config.GroupId, openedMrs)
+	service.processChecks(ctx, client, snapshot, func(mr *gitlab.MergeRequest) Checks {
+		return checks[mergeRequestKey{mr.ProjectID, mr.IID}]
+	})
+	if client.Config.IsUpdateRepeat() {
+		for _, mr := range snapshot.MergeRequests {
+			states[mergeRequestKey{mr.ProjectID, mr.IID}] = mr.State
+		}
+		service.resolveMergeRequestPosts(ctx, client, mrs, states)
+	}
+	return snapshotError(snapshot)
+}
+
+// processChecks runs checks enabled for the client against merge requests of the snapshot checksOf tells to run them against
+func (service *FiringService) processChecks(ctx context.Context, client *ConfiguredClient, snapshot *gitlabservice.Snapshot, checksOf func(mr *gitlab.MergeRequest) Checks) {
 	if len(client.Config.DiscussionFiringTimeout) > 0 {
-		service.ProcessGroupMergeRequestDiscussions(client)
+		service.ProcessGroupMergeRequestDiscussions(ctx, client, snapshot.Filter(func(mr *gitlab.MergeRequest) bool {
+			return checksOf(mr).Discussions
+		}))
 	}
-	if len(client.Config.MergeRequestOldTimeout) > 0 {
-		service.ProcessOldOpenedGroupMergeRequests(client)
+	if len(client.Config.MergeRequestOldTimeout) > 0 || len(client.EscalationSteps) > 0 {
+		service.ProcessOldOpenedGroupMergeRequests(client, snapshot.Filter(func(mr *gitlab.MergeRequest) bool {
+			return checksOf(mr).OldMergeRequest
+		}))
 	}
 	if len(client.Config.MergeRequestReviewTimeout) > 0 {
-		service.ProcessNeededReviewGroupMergeRequests(client)
+		service.ProcessNeededReviewGroupMergeRequests(ctx, client, snapshot.Filter(func(mr *gitlab.MergeRequest) bool {
+			return checksOf(mr).NeededReview
+		}))
 	}
 }
 
-func (service *FiringService) ProcessOldOpenedGroupMergeRequests(client *ConfiguredClient) {
+func allChecks(*gitlab.MergeRequest) Checks {
+	return Checks{Discussions: true, OldMergeRequest: true, NeededReview: true}
+}
+
+func snapshotError(snapshot *gitlabservice.Snapshot) error {
+	errs := snapshot.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d gitlab requests failed, first one: %v", ErrDegraded, len(errs), errs[0])
+}
+
+func (service *FiringService) ProcessOldOpenedGroupMergeRequests(client *ConfiguredClient, snapshot *gitlabservice.Snapshot) {
 	service.Log().Infof("Start processing old opened merge requests in group %d", client.Config.GroupId)
 
-	if len(client.Config.MergeRequestOldTimeout) == 0 {
+	for _, mr := range service.findOldOpenedGroupMergeRequests(client, snapshot) {
+		step := findEscalationStep(client, mr)
+		if len(client.EscalationSteps) > 0 && step == nil {
+			continue
+		}
+		record := makeOldMergeRequestRecord(client, mr, step)
+		if !service.shouldNotify(client, record) {
+			continue
+		}
+		var err error
+		if step != nil {
+			err = client.Notifier.NotifyEscalatedMergeRequest(mr, &client.Config, step)
+		} else {
+			err = client.Notifier.NotifyOldOpenedMergeRequest(mr, &client.Config)
+		}
+		if err != nil {
+			service.Log().Errorf("Failed to notify old merge request %d in project %d: %v", mr.IID, mr.ProjectID, err)
+			continue
+		}
+		service.markNotified(record)
+	}
+
+	service.Log().Infof("Finish processing old opened merge requests in group %d", client.Config.GroupId)
+}
+
+func (service *FiringService) ProcessNeededReviewGroupMergeRequests(ctx context.Context, client *ConfiguredClient, snapshot *gitlabservice.Snapshot) {
+	service.Log().Infof("Start processing needed review merge requests in group %d", client.Config.GroupId)
+
+	for _, mr := range service.findNeededReviewGroupMergeRequests(ctx, client, snapshot) {
+		record := makeNeededReviewRecord(client, mr)
+		if !service.shouldNotify(client, record) {
+			continue
+		}
+		if err := client.Notifier.NotifyNeededReviewMergeRequest(mr, &client.Config); err != nil {
+			service.Log().Errorf("Failed to notify needed review merge request %d in project %d: %v", mr.MergeRequest.IID, mr.MergeRequest.ProjectID, err)
+			continue
+		}
+		service.markNotified(record)
+	}
+
+	service.Log().Infof("Finish processing needed review merge requests in group %d", client.Config.GroupId)
+}
+
+func (service *FiringService) ProcessGroupMergeRequestDiscussions(ctx context.Context, client *ConfiguredClient, snapshot *gitlabservice.Snapshot) {
+	service.Log().Infof("Start processing firing merge request discussions in group %d", client.Config.GroupId)
+
+	for _, fmr := range service.findFiringGroupMergeRequests(ctx, client, snapshot) {
+		for i := range fmr.FiringDiscussions {
+			discussion := &fmr.FiringDiscussions[i]
+			record := makeFiringDiscussionRecord(client, &fmr.MergeRequest, discussion)
+			if !service.shouldNotify(client, record) {
+				continue
+			}
+			if err := client.Notifier.NotifyFiringDiscussion(fmr.MergeRequest, *discussion); err != nil {
+				service.Log().Errorf("Failed to notify firing discussion %s in merge request %d of project %d: %v", discussion.ID, fmr.MergeRequest.IID, fmr.MergeRequest.ProjectID, err)
+				continue
+			}
+			service.markNotified(record)
+		}
+	}
+
+	service.Log().Infof("Finish processing firing merge request discussions in group %d", client.Config.GroupId)
+}
+
+// ProcessDigest collects everything to notify about in the group and sends it as a single grouped message
+func (service *FiringService) ProcessDigest(ctx context.Context, client *ConfiguredClient, snapshot *gitlabservice.Snapshot) {
+	service.Log().Infof("Start processing digest in group %d", client.Config.GroupId)
+
+	var digest notifier.Digest
+	records := make([]*config.NotificationRecord, 0)
+
+	for _, fmr := range service.findFiringGroupMergeRequests(ctx, client, snapshot) {
+		discussions := make([]gitlab.Discussion, 0, len(fmr.FiringDiscussions))
+		for i := range fmr.FiringDiscussions {
+			record := makeFiringDiscussionRecord(client, &fmr.MergeRequest, &fmr.FiringDiscussions[i])
+			if !service.shouldNotify(client, record) {
+				continue
+			}
+			discussions = append(discussions, fmr.FiringDiscussions[i])
+			records = append(records, record)
+		}
+		if len(discussions) > 0 {
+			digest.FiringMergeRequests = append(digest.FiringMergeRequests, gitlabservice.FiringMergeRequest{
+				MergeRequest:      fmr.MergeRequest,
+				FiringDiscussions: discussions,
+			})
+		}
+	}
+
+	for _, mr := range service.findOldOpenedGroupMergeRequests(client, snapshot) {
+		step := findEscalationStep(client, mr)
+		if len(client.EscalationSteps) > 0 && step == nil {
+			continue
+		}
+		record := makeOldMergeRequestRecord(client, mr, step)
+		if !service.shouldNotify(client, record) {
+			continue
+		}
+		digest.OldMergeRequests = append(digest.OldMergeRequests, mr)
+		records = append(records, record)
+	}
+
+	for _, mr := range service.findNeededReviewGroupMergeRequests(ctx, client, snapshot) {
+		record := makeNeededReviewRecord(client, mr)
+		if !service.shouldNotify(client, record) {
+			continue
+		}
+		digest.NeededReviewMergeRequests = append(digest.NeededReviewMergeRequests, mr)
+		records = append(records, record)
+	}
+
+	if !digest.IsEmpty() {
+		digest.Incomplete = len(snapshot.Errors()) > 0
+		if err := client.Notifier.NotifyDigest(digest, &client.Config); err != nil {
+			service.Log().Errorf("Failed to notify digest of %d notifications in group %d: %v", len(records), client.Config.GroupId, err)
+			return
+		}
+		for _, record := range records {
+			service.markNotified(record)
+		}
+	}
+
+	service.Log().Infof("Finish processing digest in group %d", client.Config.GroupId)
+}
+
+// ResolvePosts marks posts about merged or closed merge requests and resolved discussions as resolved,
+// merge requests of the snapshot are known to be opened and only the rest of them are fetched
+func (service *FiringService) ResolvePosts(ctx context.Context, client *ConfiguredClient, snapshot *gitlabservice.Snapshot) {
+	posts, err := client.Notifier.UnresolvedPosts()
+	if err != nil {
+		service.Log().Errorf("Failed to get unresolved posts of client %d: %v", client.Config.Id, err)
 		return
 	}
 
-	mrOldTimeout, err := time.ParseDuration(client.Config.MergeRequestOldTimeout)
+	states := make(map[mergeRequestKey]string, len(snapshot.MergeRequests))
+	for _, mr := range snapshot.MergeRequests {
+		states[mergeRequestKey{mr.ProjectID, mr.IID}] = mr.State
+	}
+
+	service.resolvePosts(ctx, client, posts, states)
+}
+
+// resolveMergeRequestPosts resolves posts about the given merge requests only
+func (service *FiringService) resolveMergeRequestPosts(ctx context.Context, client *ConfiguredClient, mrs []*gitlab.MergeRequest, states map[mergeRequestKey]string) {
+	posts, err := client.Notifier.UnresolvedPosts()
 	if err != nil {
-		service.Log().Errorf("Failed to parse duration from %s: %v", client.Config.MergeRequestOldTimeout, err)
+		service.Log().Errorf("Failed to get unresolved posts of client %d: %v", client.Config.Id, err)
 		return
 	}
 
-	oldMrs := client.Client.MergeRequests().GetOldOpenedGroupMergeRequests(client.Config.GroupId, mrOldTimeout)
-	if len(oldMrs) > 0 {
-		service.Log().Infof("Got %d old opened merge requests in group %d", len(oldMrs), client.Config.GroupId)
+	checked := make(map[mergeRequestKey]bool, len(mrs))
+	for _, mr := range mrs {
+		checked[mergeRequestKey{mr.ProjectID, mr.IID}] = true
+	}
+	mrPosts := make([]*config.NotificationPost, 0)
+	for _, post := range posts {
+		if checked[mergeRequestKey{post.ProjectId, post.MergeRequestIid}] {
+			mrPosts = append(mrPosts, post)
+		}
 	}
 
-	for _, mr := range oldMrs {
-		client.Notifier.NotifyOldOpenedMergeRequest(mr, &client.Config)
+	service.resolvePosts(ctx, client, mrPosts, states)
+}
+
+// resolvePosts resolves the posts, states are already known states of merge requests
+// which are fetched for posts about merge requests missing in them
+func (service *FiringService) resolvePosts(ctx context.Context, client *ConfiguredClient, posts []*config.NotificationPost, states map[mergeRequestKey]string) {
+	reasons := make([]string, len(posts))
+	errs := make([]error, len(posts))
+	workerpool.Run(ctx, client.Client.Concurrency(), len(posts), func(ctx context.Context, i int) {
+		reasons[i], errs[i] = service.getResolution(ctx, client, posts[i], states)
+	})
+
+	for i, post := range posts {
+		if errs[i] != nil {
+			service.Log().Warnf("Failed to check resolution of %s post %s: %v", post.Kind, post.PostId, errs[i])
+			continue
+		}
+		reason := reasons[i]
+		if len(reason) == 0 {
+			continue
+		}
+		if err := client.Notifier.ResolvePost(post, reason); err != nil {
+			service.Log().Warnf("Failed to resolve %s post %s: %v", post.Kind, post.PostId, err)
+		}
 	}
+}
 
-	service.Log().Infof("Finish processing old opened merge requests in group %d", client.Config.GroupId)
+// getResolution returns why the item of the post doesn't require actions anymore or empty string if it still does
+func (service *FiringService) getResolution(ctx context.Context, client *ConfiguredClient, post *config.NotificationPost, states map[mergeRequestKey]string) (string, error) {
+	state, ok := states[mergeRequestKey{post.ProjectId, post.MergeRequestIid}]
+	if !ok {
+		mr, err := client.Client.MergeRequests().GetMergeRequest(ctx, post.ProjectId, post.MergeRequestIid)
+		if err != nil {
+			return "", err
+		}
+		state = mr.State
+	}
+
+	switch state {
+	case "merged":
+		return "Merge request is merged", nil
+	case "closed":
+		return "Merge request is closed", nil
+	}
+
+	if len(post.DiscussionId) == 0 {
+		return "", nil
+	}
+
+	resolved, err := client.Client.Discussions().IsMergeRequestDiscussionResolved(ctx, post.ProjectId, post.MergeRequestIid, post.DiscussionId)
+	if err != nil || !resolved {
+		return "", err
+	}
+
+	return "Discussion is resolved", nil
 }
 
-func (service *FiringService) ProcessNeededReviewGroupMergeRequests(client *ConfiguredClient) {
-	service.Log().Infof("Start processing needed review merge requests in group %d", client.Config.GroupId)
+// findOldOpenedGroupMergeRequests returns nothing if the notification is disabled or misconfigured,
+// escalation steps of the client take precedence over merge_request_old_timeout
+func (service *FiringService) findOldOpenedGroupMergeRequests(client *ConfiguredClient, snapshot *gitlabservice.Snapshot) []*gitlab.MergeRequest {
+	mrOldTimeout, ok := minEscalationTimeout(client)
+	if !ok {
+		if len(client.Config.MergeRequestOldTimeout) == 0 {
+			return nil
+		}
 
+		var err error
+		mrOldTimeout, err = time.ParseDuration(client.Config.MergeRequestOldTimeout)
+		if err != nil {
+			service.Log().Errorf("Failed to parse duration from %s: %v", client.Config.MergeRequestOldTimeout, err)
+			return nil
+		}
+	}
+
+	oldMrs := client.Client.MergeRequests().GetOldOpenedGroupMergeRequests(snapshot, mrOldTimeout)
+	if len(oldMrs) > 0 {
+		service.Log().Infof("Got %d old opened merge requests in group %d", len(oldMrs), client.Config.GroupId)
+	}
+
+	return oldMrs
+}
+
+// findNeededReviewGroupMergeRequests returns nothing if the notification is disabled or misconfigured
+func (service *FiringService) findNeededReviewGroupMergeRequests(ctx context.Context, client *ConfiguredClient, snapshot *gitlabservice.Snapshot) []*gitlabservice.MergeRequestWithParticipants {
 	if len(client.Config.MergeRequestReviewTimeout) == 0 {
-		return
+		return nil
 	}
 
 	mrReviewTimeout, err := time.ParseDuration(client.Config.MergeRequestReviewTimeout)
 	if err != nil {
 		service.Log().Errorf("Failed to parse duration from %s: %v", client.Config.MergeRequestReviewTimeout, err)
-		return
+		return nil
 	}
 
-	mrs := client.Client.MergeRequests().GetNeededReviewGroupMergeRequests(client.Config.GroupId, mrReviewTimeout, client.Config.MergeRequestReviewersCount)
+	var mrs []*gitlabservice.MergeRequestWithParticipants
+	if client.Config.IsApprovalsReview() {
+		mrs = client.Client.MergeRequests().GetNeededApprovalGroupMergeRequests(ctx, snapshot, mrReviewTimeout)
+	} else {
+		mrs = client.Client.MergeRequests().GetNeededReviewGroupMergeRequests(ctx, snapshot, mrReviewTimeout, client.Config.MergeRequestReviewersCount)
+	}
 	if len(mrs) > 0 {
 		service.Log().Infof("Got %d needed review merge requests in group %d", len(mrs), client.Config.GroupId)
 	}
 
-	for _, mr := range mrs {
-		client.Notifier.NotifyNeededReviewMergeRequest(mr, &client.Config)
-	}
-
-	service.Log().Infof("Finish processing needed review merge requests in group %d", client.Config.GroupId)
+	return mrs
 }
 
-func (service *FiringService) ProcessGroupMergeRequestDiscussions(client *ConfiguredClient) {
-	service.Log().Infof("Start processing firing merge request discussions in group %d", client.Config.GroupId)
-
+// findFiringGroupMergeRequests returns nothing if the notification is disabled or misconfigured
+func (service *FiringService) findFiringGroupMergeRequests(ctx context.Context, client *ConfiguredClient, snapshot *gitlabservice.Snapshot) []gitlabservice.FiringMergeRequest {
 	if len(client.Config.DiscussionFiringTimeout) == 0 {
-		return
+		return nil
 	}
 
 	discussionFiringTimeout, err := time.ParseDuration(client.Config.DiscussionFiringTimeout)
 	if err != nil {
 		service.Log().Errorf("Failed to parse duration from %s: %v", client.Config.DiscussionFiringTimeout, err)
-		return
+		return nil
 	}
 
-	firingMergeRequests := client.Client.Discussions().GetFiringGroupMergeRequests(client.Config.GroupId, discussionFiringTimeout)
+	firingMergeRequests := client.Client.Discussions().GetFiringGroupMergeRequests(ctx, snapshot, discussionFiringTimeout)
 	if len(firingMergeRequests) > 0 {
 		service.Log().Infof("Got %d firing merge request discussions in group %d", len(firingMergeRequests), client.Config.GroupId)
 	}
 
-	for _, fmr := range firingMergeRequests {
-		client.Notifier.NotifyFiringMergeRequestDiscussions(fmr)
-	}
-
-	service.Log().Infof("Finish processing firing merge request discussions in group %d", client.Config.GroupId)
+	return firingMergeRequests
 }
@@ -0,0 +1,48 @@
+package firingservice
+
+import (
+	"fmt"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+type ClientStore interface {
+	Get(id int) (*config.FiringConfig, error)
+}
+
+type TargetStore interface {
+	Get(clientId int, id int) (*config.Target, error)
+}
+
+// WebhookResolver makes webhooks of clients and their targets by ids from their current settings
+type WebhookResolver struct {
+	clients         ClientStore
+	targets         TargetStore
+	webhookRegistry *webhook.Registry
+}
+
+func NewWebhookResolver(clients ClientStore, targets TargetStore, webhookRegistry *webhook.Registry) *WebhookResolver {
+	return &WebhookResolver{
+		clients:         clients,
+		targets:         targets,
+		webhookRegistry: webhookRegistry,
+	}
+}
+
+// Resolve makes the webhook of the target with the id or of the client if it is 0
+func (r *WebhookResolver) Resolve(clientId int, targetId int) (webhook.Webhook, error) {
+	if targetId == 0 {
+		client, err := r.clients.Get(clientId)
+		if err != nil {
+			return nil, fmt.Errorf("get client %d: %v", clientId, err)
+		}
+		return r.webhookRegistry.Make(client.WebhookType, clientWebhookSettings(*client))
+	}
+
+	target, err := r.targets.Get(clientId, targetId)
+	if err != nil {
+		return nil, fmt.Errorf("get target %d of client %d: %v", targetId, clientId, err)
+	}
+	return r.webhookRegistry.Make(target.WebhookType, TargetWebhookSettings(target))
+}
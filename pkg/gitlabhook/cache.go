@@ -0,0 +1,75 @@
+package gitlabhook
+
+import (
+	"sync"
+	"time"
+)
+
+// MergeRequestState is the last known state of a merge request according to received events
+type MergeRequestState struct {
+	ProjectId       int
+	MergeRequestIid int
+	// State is empty until an event about the merge request itself is received
+	State          string
+	PipelineStatus string
+	UpdatedAt      time.Time
+}
+
+type mergeRequestKey struct {
+	projectId       int
+	mergeRequestIid int
+}
+
+// Cache keeps states of merge requests to tell what has changed with each event
+type Cache struct {
+	states map[mergeRequestKey]*MergeRequestState
+	mu     sync.Mutex
+}
+
+func NewCache() *Cache {
+	return &Cache{states: make(map[mergeRequestKey]*MergeRequestState)}
+}
+
+// Get returns a copy of the state of the merge request or nil if no events about it were received
+func (c *Cache) Get(projectId int, mergeRequestIid int) *MergeRequestState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.states[mergeRequestKey{projectId, mergeRequestIid}]
+	if !ok {
+		return nil
+	}
+	res := *state
+	return &res
+}
+
+// update applies the change to the state of the merge request and returns its state before the change
+func (c *Cache) update(projectId int, mergeRequestIid int, change func(state *MergeRequestState)) MergeRequestState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := mergeRequestKey{projectId, mergeRequestIid}
+	state, ok := c.states[key]
+	if !ok {
+		state = &MergeRequestState{ProjectId: projectId, MergeRequestIid: mergeRequestIid}
+		c.states[key] = state
+	}
+
+	previous := *state
+	change(state)
+	state.UpdatedAt = time.Now().UTC()
+
+	return previous
+}
+
+// DeleteUpdatedBefore forgets merge requests without events since the time
+func (c *Cache) DeleteUpdatedBefore(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, state := range c.states {
+		if state.UpdatedAt.Before(t) {
+			delete(c.states, key)
+		}
+	}
+}
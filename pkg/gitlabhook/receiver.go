@@ -0,0 +1,216 @@
+package gitlabhook
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+// Reasons of checks triggered by events
+const (
+	ReasonOpened           = "merge request opened"
+	ReasonClosed           = "merge request closed"
+	ReasonAssigned         = "reviewer assigned"
+	ReasonApproved         = "approval changed"
+	ReasonAuthorReplied    = "author replied to a thread"
+	ReasonPipelineFinished = "pipeline finished"
+)
+
+// TriggeredMergeRequest is the state of a merge request to check along with reasons events gave to check it
+type TriggeredMergeRequest struct {
+	MergeRequestState
+	Reasons map[string]bool
+}
+
+// CheckFunc checks the merge requests of the client right away given their states known from events
+type CheckFunc func(clientId int, mergeRequests []TriggeredMergeRequest)
+
+// Receiver keeps the cache up to date with received events and triggers checks of merge requests
+// when an event may change what clients are notified about,
+// checks are debounced so that a burst of events results in a single check of all merge requests they are about
+type Receiver struct {
+	cache    *Cache
+	check    CheckFunc
+	debounce time.Duration
+	timers   map[int]*time.Timer
+	// pending are merge requests of each client with reasons to check them once its timer fires
+	pending map[int]map[mergeRequestKey]map[string]bool
+	mu      sync.Mutex
+	log.Loggable
+}
+
+func NewReceiver(cache *Cache, check CheckFunc, debounce time.Duration) *Receiver {
+	return &Receiver{
+		cache:    cache,
+		check:    check,
+		debounce: debounce,
+		timers:   make(map[int]*time.Timer),
+		pending:  make(map[int]map[mergeRequestKey]map[string]bool),
+	}
+}
+
+// IsSupported tells whether events of the type are handled, others are acknowledged and ignored
+func IsSupported(eventType gitlab.EventType) bool {
+	switch eventType {
+	case gitlab.EventTypeMergeRequest, gitlab.EventTypeNote, gitlab.EventConfidentialNote, gitlab.EventTypePipeline:
+		return true
+	}
+	return false
+}
+
+// Receive applies the event to the cache and triggers checks of the clients the event was sent to
+func (r *Receiver) Receive(clientIds []int, eventType gitlab.EventType, payload []byte) error {
+	if !IsSupported(eventType) {
+		return nil
+	}
+
+	event, err := gitlab.ParseWebhook(eventType, payload)
+	if err != nil {
+		return fmt.Errorf("parse %s event: %v", eventType, err)
+	}
+
+	key, reason := r.apply(event)
+	if len(reason) == 0 {
+		return nil
+	}
+
+	for _, clientId := range clientIds {
+		r.trigger(clientId, key, reason)
+	}
+
+	return nil
+}
+
+// apply updates the cache with the event and returns the merge request it is about along with the reason to check it
+// or empty reason if there is none
+func (r *Receiver) apply(event interface{}) (mergeRequestKey, string) {
+	switch event := event.(type) {
+	case *gitlab.MergeEvent:
+		attrs := event.ObjectAttributes
+		return mergeRequestKey{attrs.TargetProjectID, attrs.IID}, r.applyMergeEvent(event)
+	case *gitlab.MergeCommentEvent:
+		mr := event.MergeRequest
+		return mergeRequestKey{mr.TargetProjectID, mr.IID}, r.applyMergeCommentEvent(event)
+	case *gitlab.PipelineEvent:
+		mr := event.MergeRequest
+		return mergeRequestKey{mr.TargetProjectID, mr.IID}, r.applyPipelineEvent(event)
+	}
+	return mergeRequestKey{}, ""
+}
+
+func (r *Receiver) applyMergeEvent(event *gitlab.MergeEvent) string {
+	attrs := event.ObjectAttributes
+	r.cache.update(attrs.TargetProjectID, attrs.IID, func(state *MergeRequestState) {
+		state.State = attrs.State
+	})
+
+	switch attrs.Action {
+	case "open", "reopen":
+		return ReasonOpened
+	case "close", "merge":
+		return ReasonClosed
+	case "approved", "unapproved":
+		return ReasonApproved
+	case "update":
+		if hasNewAssignees(event.Changes.Assignees.Previous, event.Changes.Assignees.Current) {
+			return ReasonAssigned
+		}
+	}
+	return ""
+}
+
+func (r *Receiver) applyMergeCommentEvent(event *gitlab.MergeCommentEvent) string {
+	attrs := event.ObjectAttributes
+	if attrs.System {
+		return ""
+	}
+
+	mr := event.MergeRequest
+	r.cache.update(mr.TargetProjectID, mr.IID, func(state *MergeRequestState) {
+		state.State = mr.State
+	})
+
+	// a reply of the author may put a thread back on reviewers,
+	// notes of others only make threads wait for the author which polling catches up with
+	if attrs.AuthorID == mr.AuthorID && len(attrs.DiscussionID) > 0 {
+		return ReasonAuthorReplied
+	}
+	return ""
+}
+
+func (r *Receiver) applyPipelineEvent(event *gitlab.PipelineEvent) string {
+	mr := event.MergeRequest
+	if mr.IID == 0 {
+		return ""
+	}
+
+	status := event.ObjectAttributes.Status
+	previous := r.cache.update(mr.TargetProjectID, mr.IID, func(state *MergeRequestState) {
+		state.PipelineStatus = status
+	})
+
+	if previous.PipelineStatus != status && (status == "success" || status == "failed") {
+		return ReasonPipelineFinished
+	}
+	return ""
+}
+
+// trigger checks the merge request of the client along with others events were received about
+// once no more events were received for the client during the debounce period
+func (r *Receiver) trigger(clientId int, key mergeRequestKey, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Log().Debugf("Checking merge request %d in project %d of client %d as %s", key.mergeRequestIid, key.projectId, clientId, reason)
+
+	if r.pending[clientId] == nil {
+		r.pending[clientId] = make(map[mergeRequestKey]map[string]bool)
+	}
+	if r.pending[clientId][key] == nil {
+		r.pending[clientId][key] = make(map[string]bool)
+	}
+	r.pending[clientId][key][reason] = true
+
+	// a timer which has already fired is left to run its check and a new one is started
+	if timer, ok := r.timers[clientId]; ok && timer.Stop() {
+		timer.Reset(r.debounce)
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(r.debounce, func() {
+		r.mu.Lock()
+		if r.timers[clientId] == timer {
+			delete(r.timers, clientId)
+		}
+		keys := r.pending[clientId]
+		delete(r.pending, clientId)
+		r.mu.Unlock()
+
+		mrs := make([]TriggeredMergeRequest, 0, len(keys))
+		for key, reasons := range keys {
+			if state := r.cache.Get(key.projectId, key.mergeRequestIid); state != nil {
+				mrs = append(mrs, TriggeredMergeRequest{MergeRequestState: *state, Reasons: reasons})
+			}
+		}
+		r.check(clientId, mrs)
+	})
+	r.timers[clientId] = timer
+}
+
+func hasNewAssignees(previous []gitlab.MergeAssignee, current []gitlab.MergeAssignee) bool {
+	known := make(map[string]bool, len(previous))
+	for _, assignee := range previous {
+		known[assignee.Username] = true
+	}
+	for _, assignee := range current {
+		if !known[assignee.Username] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package gitlabservice
+
+import "github.com/xanzy/go-gitlab"
+
+// ApprovalStatus is how far the merge request is from being approved according to its approvals and approval rules
+type ApprovalStatus struct {
+	ApprovalsGiven    int
+	ApprovalsRequired int
+	ApprovalsLeft     int
+	// MissingRules are approval rules which are not satisfied yet
+	MissingRules []MissingApprovalRule
+}
+
+// MissingApprovalRule is an approval rule of the merge request lacking approvals
+type MissingApprovalRule struct {
+	Name          string
+	ApprovalsLeft int
+	// Approvers are users eligible to approve the rule who have not approved the merge request yet except its author,
+	// empty if any member of the project may approve it
+	Approvers []*gitlab.BasicUser
+}
+
+func NewApprovalStatus(mr *gitlab.MergeRequest, approvals *gitlab.MergeRequestApprovals, state *gitlab.MergeRequestApprovalState) *ApprovalStatus {
+	status := &ApprovalStatus{
+		ApprovalsGiven:    len(approvals.ApprovedBy),
+		ApprovalsRequired: approvals.ApprovalsRequired,
+		ApprovalsLeft:     approvals.ApprovalsLeft,
+	}
+
+	approvedBy := make(map[int]bool, len(approvals.ApprovedBy))
+	for _, approver := range approvals.ApprovedBy {
+		if approver.User != nil {
+			approvedBy[approver.User.ID] = true
+		}
+	}
+
+	for _, rule := range state.Rules {
+		// optional rules never block merging
+		if rule.Approved || rule.ApprovalsRequired == 0 {
+			continue
+		}
+
+		missing := MissingApprovalRule{Name: rule.Name, ApprovalsLeft: rule.ApprovalsRequired - len(rule.ApprovedBy)}
+		if missing.ApprovalsLeft < 1 {
+			missing.ApprovalsLeft = 1
+		}
+		for _, user := range rule.EligibleApprovers {
+			if approvedBy[user.ID] || (mr.Author != nil && user.ID == mr.Author.ID) {
+				continue
+			}
+			missing.Approvers = append(missing.Approvers, user)
+		}
+		status.MissingRules = append(status.MissingRules, missing)
+	}
+
+	return status
+}
+
+// IsApproved reports whether the merge request has all approvals it requires
+func (s *ApprovalStatus) IsApproved() bool {
+	return s.ApprovalsLeft == 0 && len(s.MissingRules) == 0
+}
+
+// MissingApprovers returns users eligible to approve any of missing rules
+func (s *ApprovalStatus) MissingApprovers() []*gitlab.BasicUser {
+	seen := make(map[int]bool)
+	users := make([]*gitlab.BasicUser, 0)
+	for _, rule := range s.MissingRules {
+		for _, user := range rule.Approvers {
+			if !seen[user.ID] {
+				seen[user.ID] = true
+				users = append(users, user)
+			}
+		}
+	}
+	return users
+}
+
+func approvedByUsers(approvals *gitlab.MergeRequestApprovals) []*gitlab.BasicUser {
+	users := make([]*gitlab.BasicUser, 0, len(approvals.ApprovedBy))
+	for _, approver := range approvals.ApprovedBy {
+		if approver.User != nil {
+			users = append(users, approver.User)
+		}
+	}
+	return users
+}
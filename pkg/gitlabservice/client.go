@@ -13,25 +13,57 @@ import (
 type Client struct {
 	discussions   *DiscussionsService
 	mergeRequests *MergeRequestsService
+	projects      *ProjectsService
+	users         *UsersService
+	concurrency   int
 	log.Loggable
 }
 
-func NewClient(gitlabToken string, gitlabUrl string) (*Client, error) {
+type ClientOptions struct {
+	// MergeRequestsLimit caps the number of merge requests listed in a group, 0 means no limit
+	MergeRequestsLimit int
+	// Concurrency is how many requests about merge requests of a group are sent at once
+	Concurrency int
+	// ResponseCache revalidates responses of gitlab API, nil disables caching
+	ResponseCache *ResponseCache
+	// DiscussionCache skips fetching discussions of merge requests which are not updated, nil disables skipping
+	DiscussionCache *DiscussionCache
+	// RateLimiter spaces requests according to the rate limit of gitlab, nil disables limiting
+	RateLimiter *RateLimiter
+}
+
+func NewClient(gitlabToken string, gitlabUrl string, options ClientOptions) (*Client, error) {
 	httpTransport := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
 		},
 	}
-	httpClient := &http.Client{Transport: httpTransport}
+	// revalidated responses count towards the rate limit as well so the limiter is under the cache
+	var transport http.RoundTripper = httpTransport
+	if options.RateLimiter != nil {
+		transport = options.RateLimiter.Transport(transport)
+	}
+	if options.ResponseCache != nil {
+		transport = options.ResponseCache.Transport(transport)
+	}
+	httpClient := &http.Client{Transport: transport}
 
-	client, err := gitlab.NewClient(gitlabToken, gitlab.WithBaseURL(gitlabUrl), gitlab.WithHTTPClient(httpClient))
+	client, err := gitlab.NewClient(
+		gitlabToken,
+		gitlab.WithBaseURL(gitlabUrl),
+		gitlab.WithHTTPClient(httpClient),
+		gitlab.WithCustomBackoff(retryBackoff),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("create gitlab client: %v", err)
 	}
 
 	return &Client{
-		discussions:   NewDiscussionsService(client),
-		mergeRequests: NewMergeRequestsService(client),
+		discussions:   NewDiscussionsService(client, options.DiscussionCache, gitlabToken),
+		mergeRequests: NewMergeRequestsService(client, NewMergeRequestLister(client, options.MergeRequestsLimit)),
+		projects:      NewProjectsService(client),
+		users:         NewUsersService(client),
+		concurrency:   options.Concurrency,
 	}, nil
 }
 
@@ -43,14 +75,31 @@ func (client *Client) MergeRequests() *MergeRequestsService {
 	return client.mergeRequests
 }
 
+func (client *Client) Projects() *ProjectsService {
+	return client.projects
+}
+
+func (client *Client) Users() *UsersService {
+	return client.users
+}
+
+// Concurrency is how many requests about merge requests of a group are sent at once
+func (client *Client) Concurrency() int {
+	return client.concurrency
+}
+
+// ClientFactory makes clients of the gitlab instance sharing the options, caches among them
 type ClientFactory struct {
 	gitlabUrl string
+	options   ClientOptions
 }
 
-func NewInstancedClientFactory(gitlabUrl string) *ClientFactory {
-	return &ClientFactory{gitlabUrl: gitlabUrl}
+func NewInstancedClientFactory(gitlabUrl string, options ClientOptions) *ClientFactory {
+	return &ClientFactory{gitlabUrl: gitlabUrl, options: options}
 }
 
-func (f *ClientFactory) MakeClient(gitlabToken string) (*Client, error) {
-	return NewClient(gitlabToken, f.gitlabUrl)
+func (f *ClientFactory) MakeClient(gitlabToken string, mergeRequestsLimit int) (*Client, error) {
+	options := f.options
+	options.MergeRequestsLimit = mergeRequestsLimit
+	return NewClient(gitlabToken, f.gitlabUrl, options)
 }
@@ -0,0 +1,89 @@
+package gitlabservice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// discussionCacheKey includes the hash of the token the discussions are fetched with
+// as users with different tokens may not have access to the same projects
+type discussionCacheKey struct {
+	tokenHash       string
+	projectId       int
+	mergeRequestIid int
+}
+
+type discussionCacheEntry struct {
+	updatedAt   time.Time
+	fetchedAt   time.Time
+	discussions []*gitlab.Discussion
+}
+
+// DiscussionCache keeps discussions of merge requests between runs to skip fetching them while merge requests
+// are not updated, discussions are fetched again after maxAge anyway in case a change didn't update its merge request.
+// Cached discussions are shared by clients with the same token and must not be modified
+type DiscussionCache struct {
+	entries map[discussionCacheKey]discussionCacheEntry
+	maxAge  time.Duration
+	mu      sync.Mutex
+}
+
+func NewDiscussionCache(maxAge time.Duration) *DiscussionCache {
+	return &DiscussionCache{
+		entries: make(map[discussionCacheKey]discussionCacheEntry),
+		maxAge:  maxAge,
+	}
+}
+
+// Get returns discussions of the merge request fetched with the token if it wasn't updated since they were fetched
+func (c *DiscussionCache) Get(tokenHash string, mr *gitlab.MergeRequest) ([]*gitlab.Discussion, bool) {
+	if c == nil || mr.UpdatedAt == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[discussionCacheKey{tokenHash, mr.ProjectID, mr.IID}]
+	if !ok || !entry.updatedAt.Equal(*mr.UpdatedAt) || time.Since(entry.fetchedAt) > c.maxAge {
+		return nil, false
+	}
+	return entry.discussions, true
+}
+
+func (c *DiscussionCache) Put(tokenHash string, mr *gitlab.MergeRequest, discussions []*gitlab.Discussion) {
+	if c == nil || mr.UpdatedAt == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[discussionCacheKey{tokenHash, mr.ProjectID, mr.IID}] = discussionCacheEntry{
+		updatedAt:   *mr.UpdatedAt,
+		fetchedAt:   time.Now(),
+		discussions: discussions,
+	}
+}
+
+// DeleteExpired forgets discussions which would be fetched again anyway
+func (c *DiscussionCache) DeleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if time.Since(entry.fetchedAt) > c.maxAge {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// hashToken identifies the token in cache keys without keeping the token itself
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -1,7 +1,8 @@
 package gitlabservice
 
 import (
-	"io/ioutil"
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/xanzy/go-gitlab"
@@ -11,21 +12,30 @@ import (
 
 type DiscussionsService struct {
 	client *gitlab.Client
+	// cache is nil if discussions are fetched on each run
+	cache *DiscussionCache
+	// tokenHash separates cached discussions of different tokens
+	tokenHash string
 	log.Loggable
 }
 
-func NewDiscussionsService(client *gitlab.Client) *DiscussionsService {
-	return &DiscussionsService{client: client}
+func NewDiscussionsService(client *gitlab.Client, cache *DiscussionCache, gitlabToken string) *DiscussionsService {
+	return &DiscussionsService{client: client, cache: cache, tokenHash: hashToken(gitlabToken)}
 }
 
-func (service *DiscussionsService) GetFiringGroupMergeRequests(groupId int, timeout time.Duration) []FiringMergeRequest {
+func (service *DiscussionsService) GetFiringGroupMergeRequests(ctx context.Context, snapshot *Snapshot, timeout time.Duration) []FiringMergeRequest {
 	firingMergeRequests := make([]FiringMergeRequest, 0, 5)
 
-	mrs := service.GetOpenedGroupMergeRequests(groupId)
-	service.Log().Debugf("Received %d merge requests for group %d", len(mrs), groupId)
+	service.Log().Debugf("Received %d merge requests for group %d", len(snapshot.MergeRequests), snapshot.GroupId)
 
-	for _, mr := range mrs {
-		firingMergeRequestDiscussions := service.GetFiringMergeRequestDiscussions(mr, timeout)
+	discussions := snapshot.Discussions(ctx)
+	if err := ctx.Err(); err != nil {
+		service.Log().Warnf("Failed to get discussions of merge requests in group %d: %v", snapshot.GroupId, err)
+		return nil
+	}
+
+	for i, mr := range snapshot.MergeRequests {
+		firingMergeRequestDiscussions := service.filterFiringDiscussions(mr, discussions[i], timeout)
 		// MR is consider firing then it contains a firing discussions
 		if len(firingMergeRequestDiscussions) > 0 {
 			service.Log().Debugf(
@@ -45,23 +55,9 @@ func (service *DiscussionsService) GetFiringGroupMergeRequests(groupId int, time
 	return firingMergeRequests
 }
 
-func (service *DiscussionsService) GetOpenedGroupMergeRequests(groupId int) []*gitlab.MergeRequest {
-	mrs, _, err := service.client.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
-		State:       gitlab.String("opened"),
-		ListOptions: gitlab.ListOptions{PerPage: 100},
-	})
-	if err != nil {
-		service.Log().Warnf("Failed to ListGroupMergeRequests for group %d: %v", groupId, err)
-		return nil
-	}
-	return mrs
-}
-
-func (service *DiscussionsService) GetFiringMergeRequestDiscussions(mr *gitlab.MergeRequest, timeout time.Duration) []gitlab.Discussion {
+func (service *DiscussionsService) filterFiringDiscussions(mr *gitlab.MergeRequest, discussions []*gitlab.Discussion, timeout time.Duration) []gitlab.Discussion {
 	outdatedDiscussions := make([]gitlab.Discussion, 0, 5)
 
-	discussions := service.GetMergeRequestDiscussions(mr)
-
 	service.Log().Debugf(
 		"Got %d discussions for merge request %d in project %d",
 		len(discussions),
@@ -69,61 +65,66 @@ func (service *DiscussionsService) GetFiringMergeRequestDiscussions(mr *gitlab.M
 		mr.ProjectID,
 	)
 
-	for _, discussion := range discussions {
+	for _, fetched := range discussions {
+		// discussions may be shared with other clients by the cache so the sanitized one is a copy
+		discussion := *fetched
 		discussion.Notes = sanitizeNotes(discussion.Notes)
-		if service.IsDiscussionFiring(mr, discussion, timeout) {
+		if service.IsDiscussionFiring(mr, &discussion, timeout) {
 			service.Log().Debugf(
 				"Found firing discussion %s in merge request %d of project %d",
 				discussion.ID,
 				mr.IID,
 				mr.ProjectID,
 			)
-			outdatedDiscussions = append(outdatedDiscussions, *discussion)
+			outdatedDiscussions = append(outdatedDiscussions, discussion)
 		}
 	}
 
 	return outdatedDiscussions
 }
 
-func (service *DiscussionsService) GetMergeRequestDiscussions(mr *gitlab.MergeRequest) []*gitlab.Discussion {
+// GetMergeRequestDiscussions returns discussions cached since the merge request was updated the last time
+// or fetches them otherwise
+func (service *DiscussionsService) GetMergeRequestDiscussions(ctx context.Context, mr *gitlab.MergeRequest) ([]*gitlab.Discussion, error) {
+	if discussions, ok := service.cache.Get(service.tokenHash, mr); ok {
+		return discussions, nil
+	}
+
+	discussions, err := service.listMergeRequestDiscussions(ctx, mr)
+	if err != nil {
+		return nil, fmt.Errorf("get discussions of merge request %d in project %d: %w", mr.IID, mr.ProjectID, err)
+	}
+
+	service.cache.Put(service.tokenHash, mr, discussions)
+	return discussions, nil
+}
+
+func (service *DiscussionsService) listMergeRequestDiscussions(ctx context.Context, mr *gitlab.MergeRequest) ([]*gitlab.Discussion, error) {
 	discussions := make([]*gitlab.Discussion, 0, 10)
-	page := 1
-	perPage := 100
+	opts := &gitlab.ListMergeRequestDiscussionsOptions{Page: 1, PerPage: 100}
 
 	for {
-		pageDiscussions := service.GetMergeRequestDiscussionsPaged(mr, page, perPage)
+		pageDiscussions, resp, err := service.client.Discussions.ListMergeRequestDiscussions(mr.ProjectID, mr.IID, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("list discussions on page %d: %w", opts.Page, err)
+		}
+
 		discussions = append(discussions, pageDiscussions...)
-		page++
-		if len(pageDiscussions) < perPage {
-			return discussions
+		if resp.NextPage == 0 {
+			return discussions, nil
 		}
+		opts.Page = resp.NextPage
 	}
 }
 
-func (service *DiscussionsService) GetMergeRequestDiscussionsPaged(mr *gitlab.MergeRequest, page int, perPage int) []*gitlab.Discussion {
-	pageDiscussions, resp, err := service.client.Discussions.ListMergeRequestDiscussions(
-		mr.ProjectID,
-		mr.IID,
-		&gitlab.ListMergeRequestDiscussionsOptions{
-			Page:    page,
-			PerPage: perPage,
-		},
-	)
-
+// IsMergeRequestDiscussionResolved reports whether the discussion is resolved or is not resolvable at all
+func (service *DiscussionsService) IsMergeRequestDiscussionResolved(ctx context.Context, projectId int, iid int, discussionId string) (bool, error) {
+	discussion, _, err := service.client.Discussions.GetMergeRequestDiscussion(projectId, iid, discussionId, gitlab.WithContext(ctx))
 	if err != nil {
-		service.Log().Warnf("Failed to get discussions for merge request %d in project %d: %v", mr.IID, mr.ProjectID, err)
-		return nil
+		return false, fmt.Errorf("get discussion %s of merge request %d in project %d: %v", discussionId, iid, projectId, err)
 	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		service.Log().Warnf("Failed to get discussions for merge request %d in project %d: (%d) %s", mr.IID, mr.ProjectID, resp.StatusCode, body)
-		return nil
-	}
-
-	return pageDiscussions
+	discussion.Notes = sanitizeNotes(discussion.Notes)
+	return !isDiscussionResolvable(discussion) || isDiscussionResolved(discussion), nil
 }
 
 func (service *DiscussionsService) IsDiscussionFiring(mr *gitlab.MergeRequest, discussion *gitlab.Discussion, timeout time.Duration) bool {
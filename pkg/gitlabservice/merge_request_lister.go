@@ -0,0 +1,89 @@
+package gitlabservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+const mergeRequestsPerPage = 100
+
+// mergeRequestListPasses is how many times merge requests are listed again when their total changes during listing
+const mergeRequestListPasses = 3
+
+// MergeRequestLister lists merge requests of a group through all pages,
+// gitlab supports only offset pagination of merge requests so pages are ordered by creation time
+// to keep them stable while new merge requests are opened. Merge requests closed during listing shift later ones
+// to previous pages, so once the total of merge requests changes between pages they are listed again from the first page
+// keeping merge requests of previous passes, those of them which are closed by then are left out when they are fetched
+type MergeRequestLister struct {
+	client *gitlab.Client
+	// limit caps the number of listed merge requests, 0 means no limit
+	limit int
+	log.Loggable
+}
+
+func NewMergeRequestLister(client *gitlab.Client, limit int) *MergeRequestLister {
+	return &MergeRequestLister{client: client, limit: limit}
+}
+
+func (l *MergeRequestLister) ListOpenedGroupMergeRequests(ctx context.Context, groupId int) ([]*gitlab.MergeRequest, error) {
+	mrs := make([]*gitlab.MergeRequest, 0, mergeRequestsPerPage)
+	seen := make(map[int]bool)
+
+	for pass := 1; ; pass++ {
+		shifted, err := l.listPages(ctx, groupId, &mrs, seen)
+		if err != nil || !shifted {
+			return mrs, err
+		}
+		if pass == mergeRequestListPasses {
+			l.Log().Warnf("Merge requests of group %d keep changing while they are listed, some of them may be missed", groupId)
+			return mrs, nil
+		}
+	}
+}
+
+// listPages appends merge requests which were not seen yet from all pages to mrs
+// and tells if the total of merge requests changed while pages were listed
+func (l *MergeRequestLister) listPages(ctx context.Context, groupId int, mrs *[]*gitlab.MergeRequest, seen map[int]bool) (bool, error) {
+	opts := &gitlab.ListGroupMergeRequestsOptions{
+		State:       gitlab.String("opened"),
+		OrderBy:     gitlab.String("created_at"),
+		Sort:        gitlab.String("asc"),
+		ListOptions: gitlab.ListOptions{Page: 1, PerPage: mergeRequestsPerPage},
+	}
+	// total is unknown until the first page and for large lists gitlab doesn't count
+	total := -1
+
+	for {
+		pageMrs, resp, err := l.client.MergeRequests.ListGroupMergeRequests(groupId, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return false, fmt.Errorf("list merge requests of group %d on page %d: %v", groupId, opts.Page, err)
+		}
+
+		for _, mr := range pageMrs {
+			if seen[mr.ID] {
+				continue
+			}
+			if l.limit > 0 && len(*mrs) == l.limit {
+				l.Log().Warnf("Group %d has more than %d opened merge requests, the rest are not checked", groupId, l.limit)
+				return false, nil
+			}
+			seen[mr.ID] = true
+			*mrs = append(*mrs, mr)
+		}
+
+		shifted := total >= 0 && resp.TotalItems != total
+		if total < 0 {
+			total = resp.TotalItems
+		}
+
+		if resp.NextPage == 0 || shifted {
+			return shifted, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -4,5 +4,9 @@ import "github.com/xanzy/go-gitlab"
 
 type MergeRequestWithParticipants struct {
 	MergeRequest *gitlab.MergeRequest
+	// Participants are set in participants review mode only
 	Participants []*gitlab.BasicUser
+	// ApprovedBy and Approval are set in approvals review mode only
+	ApprovedBy []*gitlab.BasicUser
+	Approval   *ApprovalStatus
 }
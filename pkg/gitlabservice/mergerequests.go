@@ -1,8 +1,9 @@
 package gitlabservice
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
+	"net/http"
 	"time"
 
 	"github.com/xanzy/go-gitlab"
@@ -12,30 +13,44 @@ import (
 
 type MergeRequestsService struct {
 	client *gitlab.Client
+	lister *MergeRequestLister
 	log.Loggable
 }
 
-func NewMergeRequestsService(client *gitlab.Client) *MergeRequestsService {
-	return &MergeRequestsService{client: client}
+func NewMergeRequestsService(client *gitlab.Client, lister *MergeRequestLister) *MergeRequestsService {
+	return &MergeRequestsService{client: client, lister: lister}
 }
 
 type predicate func(request *gitlab.MergeRequest) bool
 
-func (r *MergeRequestsService) GetOldOpenedGroupMergeRequests(groupId int, timeout time.Duration) []*gitlab.MergeRequest {
-	return r.filterGroupMergeRequests(groupId, func(mr *gitlab.MergeRequest) bool {
+func (r *MergeRequestsService) GetOldOpenedGroupMergeRequests(snapshot *Snapshot, timeout time.Duration) []*gitlab.MergeRequest {
+	return r.filterMergeRequests(snapshot.MergeRequests, func(mr *gitlab.MergeRequest) bool {
 		return !mr.WorkInProgress && isMergeRequestNotUpdatedFor(mr, timeout)
 	})
 }
 
-func (r *MergeRequestsService) GetNeededReviewGroupMergeRequests(groupId int, timeout time.Duration, reviewersCount int) []*MergeRequestWithParticipants {
+func (r *MergeRequestsService) GetNeededReviewGroupMergeRequests(ctx context.Context, snapshot *Snapshot, timeout time.Duration, reviewersCount int) []*MergeRequestWithParticipants {
 	res := make([]*MergeRequestWithParticipants, 0)
 
-	for _, mr := range r.GetOpenedGroupMergeRequests(groupId) {
-		participants := r.GetMergeRequestsParticipants(mr)
-		if !mr.WorkInProgress && isMergeRequestCreatedLongAgo(mr, timeout) && len(participants) < reviewersCount {
+	awaitingReview := func(mr *gitlab.MergeRequest) bool {
+		return !mr.WorkInProgress && isMergeRequestCreatedLongAgo(mr, timeout)
+	}
+
+	participants := snapshot.Participants(ctx, awaitingReview)
+	if err := ctx.Err(); err != nil {
+		r.Log().Warnf("Failed to get participants of merge requests in group %d: %v", snapshot.GroupId, err)
+		return nil
+	}
+
+	for i, mr := range snapshot.MergeRequests {
+		// participants failed to be fetched are unknown rather than missing
+		if !awaitingReview(mr) || participants[i] == nil {
+			continue
+		}
+		if len(participants[i]) < reviewersCount {
 			res = append(res, &MergeRequestWithParticipants{
 				MergeRequest: mr,
-				Participants: r.GetMergeRequestsParticipants(mr),
+				Participants: participants[i],
 			})
 		}
 	}
@@ -43,51 +58,87 @@ func (r *MergeRequestsService) GetNeededReviewGroupMergeRequests(groupId int, ti
 	return res
 }
 
-func (r *MergeRequestsService) GetOpenedGroupMergeRequests(groupId int) []*gitlab.MergeRequest {
-	fullMrs := make([]*gitlab.MergeRequest, 0)
+// GetNeededApprovalGroupMergeRequests returns merge requests lacking approvals required by gitlab
+// along with users who approved them and approvers still missing by approval rule
+func (r *MergeRequestsService) GetNeededApprovalGroupMergeRequests(ctx context.Context, snapshot *Snapshot, timeout time.Duration) []*MergeRequestWithParticipants {
+	res := make([]*MergeRequestWithParticipants, 0)
 
-	mrs, resp, err := r.client.MergeRequests.ListGroupMergeRequests(groupId, &gitlab.ListGroupMergeRequestsOptions{
-		State:       gitlab.String("opened"),
-		ListOptions: gitlab.ListOptions{PerPage: 100},
-	})
-	if err != nil {
-		r.Log().Warnf("Failed to ListGroupMergeRequests for group %d: %v", groupId, err)
-		return nil
+	awaitingApproval := func(mr *gitlab.MergeRequest) bool {
+		return !mr.WorkInProgress && isMergeRequestCreatedLongAgo(mr, timeout)
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		r.Log().Warnf("Failed to ListGroupMergeRequests for group %d: (%d) %s", groupId, resp.StatusCode, body)
+	approvals := snapshot.Approvals(ctx, awaitingApproval)
+	states := snapshot.ApprovalStates(ctx, awaitingApproval)
+	if err := ctx.Err(); err != nil {
+		r.Log().Warnf("Failed to get approvals of merge requests in group %d: %v", snapshot.GroupId, err)
 		return nil
 	}
 
-	for _, mr := range mrs {
-		fullMr, resp, err := r.client.MergeRequests.GetMergeRequestChanges(mr.ProjectID, mr.IID)
-		if err != nil || resp.StatusCode != 200 {
-			r.Log().Warnf("Failed to GetMergeRequestChanges for MR %d in project %d: %v", mr.IID, mr.ProjectID, err)
-			return nil
+	for i, mr := range snapshot.MergeRequests {
+		// approvals failed to be fetched are unknown rather than missing
+		if !awaitingApproval(mr) || approvals[i] == nil || states[i] == nil {
+			continue
+		}
+		status := NewApprovalStatus(mr, approvals[i], states[i])
+		if !status.IsApproved() {
+			res = append(res, &MergeRequestWithParticipants{
+				MergeRequest: mr,
+				ApprovedBy:   approvedByUsers(approvals[i]),
+				Approval:     status,
+			})
 		}
-		fullMrs = append(fullMrs, fullMr)
 	}
 
-	return fullMrs
+	return res
+}
+
+func (r *MergeRequestsService) ListOpenedGroupMergeRequests(ctx context.Context, groupId int) ([]*gitlab.MergeRequest, error) {
+	return r.lister.ListOpenedGroupMergeRequests(ctx, groupId)
 }
 
-func (r *MergeRequestsService) GetMergeRequestsParticipants(mr *gitlab.MergeRequest) []*gitlab.BasicUser {
-	allParticipants, resp, err := r.GetMergeRequestParticipants(mr.ProjectID, mr.IID)
+// GetMergeRequestChanges returns the merge request with its changes
+func (r *MergeRequestsService) GetMergeRequestChanges(ctx context.Context, mr *gitlab.MergeRequest) (*gitlab.MergeRequest, error) {
+	fullMr, _, err := r.client.MergeRequests.GetMergeRequestChanges(mr.ProjectID, mr.IID, gitlab.WithContext(ctx))
 	if err != nil {
-		r.Log().Warnf("Failed to ListGroupMergeRequests for MR %d in project %d: %v", mr.IID, mr.ProjectID, err)
-		return nil
+		return nil, fmt.Errorf("get changes of merge request %d in project %d: %w", mr.IID, mr.ProjectID, err)
 	}
+	return fullMr, nil
+}
 
-	defer resp.Body.Close()
+func (r *MergeRequestsService) GetMergeRequest(ctx context.Context, projectId int, iid int) (*gitlab.MergeRequest, error) {
+	mr, _, err := r.client.MergeRequests.GetMergeRequest(projectId, iid, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get merge request %d in project %d: %v", iid, projectId, err)
+	}
+	return mr, nil
+}
 
-	if resp.StatusCode != 200 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		r.Log().Warnf("Failed to ListGroupMergeRequests for MR %d in project %d: (%d) %s", mr.IID, mr.ProjectID, resp.StatusCode, body)
-		return nil
+func (r *MergeRequestsService) GetMergeRequestApprovals(ctx context.Context, mr *gitlab.MergeRequest) (*gitlab.MergeRequestApprovals, error) {
+	approvals, _, err := r.client.MergeRequests.GetMergeRequestApprovals(mr.ProjectID, mr.IID, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get approvals of merge request %d in project %d: %w", mr.IID, mr.ProjectID, err)
+	}
+	return approvals, nil
+}
+
+// GetMergeRequestApprovalState returns approval rules of the merge request with users eligible to approve them,
+// gitlab editions without approval rules return no rules
+func (r *MergeRequestsService) GetMergeRequestApprovalState(ctx context.Context, mr *gitlab.MergeRequest) (*gitlab.MergeRequestApprovalState, error) {
+	state, resp, err := r.client.MergeRequestApprovals.GetApprovalState(mr.ProjectID, mr.IID, gitlab.WithContext(ctx))
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return &gitlab.MergeRequestApprovalState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get approval state of merge request %d in project %d: %w", mr.IID, mr.ProjectID, err)
+	}
+	return state, nil
+}
+
+// GetMergeRequestsParticipants returns participants of the merge request except its author
+func (r *MergeRequestsService) GetMergeRequestsParticipants(ctx context.Context, mr *gitlab.MergeRequest) ([]*gitlab.BasicUser, error) {
+	allParticipants, _, err := r.GetMergeRequestParticipants(mr.ProjectID, mr.IID, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get participants of merge request %d in project %d: %w", mr.IID, mr.ProjectID, err)
 	}
 
 	// removing the author from participants
@@ -98,7 +149,7 @@ func (r *MergeRequestsService) GetMergeRequestsParticipants(mr *gitlab.MergeRequ
 		}
 	}
 
-	return participants
+	return participants, nil
 }
 
 // TODO remove after pull request will be approval
@@ -119,10 +170,6 @@ func (r *MergeRequestsService) GetMergeRequestParticipants(project int, mergeReq
 	return p, resp, err
 }
 
-func (r *MergeRequestsService) filterGroupMergeRequests(groupId int, predicate predicate) []*gitlab.MergeRequest {
-	return r.filterMergeRequests(r.GetOpenedGroupMergeRequests(groupId), predicate)
-}
-
 func (r *MergeRequestsService) filterMergeRequests(mrs []*gitlab.MergeRequest, predicate predicate) []*gitlab.MergeRequest {
 	result := make([]*gitlab.MergeRequest, 0)
 	for _, mr := range mrs {
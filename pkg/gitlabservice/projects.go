@@ -0,0 +1,47 @@
+package gitlabservice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+const projectsPerPage = 100
+
+type ProjectsService struct {
+	client *gitlab.Client
+	log.Loggable
+}
+
+func NewProjectsService(client *gitlab.Client) *ProjectsService {
+	return &ProjectsService{client: client}
+}
+
+// GetGroupProjectIds returns ids of projects of the group and its subgroups
+func (service *ProjectsService) GetGroupProjectIds(ctx context.Context, groupId int) (map[int]bool, error) {
+	opts := &gitlab.ListGroupProjectsOptions{
+		Simple:           gitlab.Bool(true),
+		IncludeSubgroups: gitlab.Bool(true),
+		ListOptions:      gitlab.ListOptions{Page: 1, PerPage: projectsPerPage},
+	}
+
+	ids := make(map[int]bool)
+	for {
+		projects, resp, err := service.client.Groups.ListGroupProjects(groupId, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("list projects of group %d on page %d: %v", groupId, opts.Page, err)
+		}
+
+		for _, project := range projects {
+			ids[project.ID] = true
+		}
+
+		if resp.NextPage == 0 {
+			return ids, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
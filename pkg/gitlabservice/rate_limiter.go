@@ -0,0 +1,166 @@
+package gitlabservice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+const (
+	// rateLimitWindow is the reset period assumed when a throttled response doesn't tell when to retry
+	rateLimitWindow = time.Minute
+	retryBaseDelay  = time.Second
+	retryMaxDelay   = 30 * time.Second
+)
+
+// RateLimiter spaces requests to gitlab made with a token so that they fit the rate limit of the token,
+// remaining requests are spread evenly until the limit resets according to headers of the latest response
+type RateLimiter struct {
+	buckets map[string]*rateBucket
+	mu      sync.Mutex
+}
+
+type rateBucket struct {
+	remaining int
+	reset     time.Time
+	// next is when the next request is allowed
+	next time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*rateBucket)}
+}
+
+// Transport wraps the transport to limit requests sent through it
+func (l *RateLimiter) Transport(next http.RoundTripper) http.RoundTripper {
+	return &rateLimitedTransport{limiter: l, next: next}
+}
+
+// reserve returns how long the request has to wait before it is sent
+func (l *RateLimiter) reserve(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok || !now.Before(b.reset) {
+		return 0
+	}
+
+	if b.remaining <= 0 {
+		return b.reset.Sub(now)
+	}
+
+	at := now
+	if b.next.After(at) {
+		at = b.next
+	}
+	b.next = at.Add(b.reset.Sub(at) / time.Duration(b.remaining))
+	b.remaining--
+
+	return at.Sub(now)
+}
+
+// update takes the rate limit state of the token from headers of the response
+func (l *RateLimiter) update(key string, resp *http.Response, now time.Time) {
+	remaining, err := strconv.Atoi(resp.Header.Get(headerRateLimitRemaining))
+	throttled := resp.StatusCode == http.StatusTooManyRequests
+	if err != nil && !throttled {
+		return
+	}
+	if throttled {
+		remaining = 0
+	}
+
+	reset := now.Add(rateLimitWindow)
+	if wait, ok := parseRetryWait(resp, now); ok {
+		reset = now.Add(wait)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &rateBucket{}
+		l.buckets[key] = b
+	}
+	b.remaining = remaining
+	b.reset = reset
+}
+
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := tokenKey(req)
+
+	if wait := t.limiter.reserve(key, time.Now()); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.limiter.update(key, resp, time.Now())
+	return resp, nil
+}
+
+// retryBackoff is how long gitlab client waits before it retries the failed request,
+// throttled requests wait until the rate limit resets, others back off exponentially with jitter
+func retryBackoff(_, _ time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if wait, ok := parseRetryWait(resp, time.Now()); ok {
+			return wait
+		}
+	}
+
+	delay := retryBaseDelay
+	for i := 0; i < attemptNum && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryWait returns how long to wait according to Retry-After or RateLimit-Reset headers of the response
+func parseRetryWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(resp.Header.Get(headerRetryAfter)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get(headerRateLimitReset), 10, 64); err == nil && reset > 0 {
+		if wait := time.Unix(reset, 0).Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// tokenKey identifies the token of the request without keeping the token itself
+func tokenKey(req *http.Request) string {
+	hash := sha256.Sum256([]byte(req.Header.Get("Private-Token") + "\n" + req.Header.Get("Authorization")))
+	return hex.EncodeToString(hash[:])
+}
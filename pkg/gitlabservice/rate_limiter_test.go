@@ -0,0 +1,244 @@
+package gitlabservice
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var rateLimitNow = time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func makeRateLimitResponse(statusCode int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: statusCode, Header: make(http.Header)}
+	for name, value := range headers {
+		resp.Header.Set(name, value)
+	}
+	return resp
+}
+
+func unixHeader(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+func TestParseRetryWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantWait time.Duration
+		wantOk   bool
+	}{
+		{
+			name:   "no headers",
+			wantOk: false,
+		},
+		{
+			name:     "retry after",
+			headers:  map[string]string{headerRetryAfter: "15"},
+			wantWait: 15 * time.Second,
+			wantOk:   true,
+		},
+		{
+			name: "retry after takes precedence over reset",
+			headers: map[string]string{
+				headerRetryAfter:     "15",
+				headerRateLimitReset: unixHeader(rateLimitNow.Add(time.Minute)),
+			},
+			wantWait: 15 * time.Second,
+			wantOk:   true,
+		},
+		{
+			name:     "reset in the future",
+			headers:  map[string]string{headerRateLimitReset: unixHeader(rateLimitNow.Add(40 * time.Second))},
+			wantWait: 40 * time.Second,
+			wantOk:   true,
+		},
+		{
+			name:     "reset already passed",
+			headers:  map[string]string{headerRateLimitReset: unixHeader(rateLimitNow.Add(-time.Minute))},
+			wantWait: 0,
+			wantOk:   true,
+		},
+		{
+			name:     "retry after is a date",
+			headers:  map[string]string{headerRetryAfter: "Mon, 01 Jun 2020 10:01:00 GMT"},
+			wantWait: 0,
+			wantOk:   false,
+		},
+		{
+			name:     "negative retry after",
+			headers:  map[string]string{headerRetryAfter: "-1"},
+			wantWait: 0,
+			wantOk:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := makeRateLimitResponse(http.StatusTooManyRequests, tt.headers)
+			wait, ok := parseRetryWait(resp, rateLimitNow)
+			if wait != tt.wantWait || ok != tt.wantOk {
+				t.Errorf("parseRetryWait() = %v, %v, want %v, %v", wait, ok, tt.wantWait, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		headers       map[string]string
+		wantBucket    bool
+		wantRemaining int
+		wantReset     time.Time
+	}{
+		{
+			name:       "no rate limit headers",
+			statusCode: http.StatusOK,
+			wantBucket: false,
+		},
+		{
+			name:       "remaining and reset",
+			statusCode: http.StatusOK,
+			headers: map[string]string{
+				headerRateLimitRemaining: "10",
+				headerRateLimitReset:     unixHeader(rateLimitNow.Add(30 * time.Second)),
+			},
+			wantBucket:    true,
+			wantRemaining: 10,
+			wantReset:     rateLimitNow.Add(30 * time.Second),
+		},
+		{
+			name:       "remaining without reset assumes the default window",
+			statusCode: http.StatusOK,
+			headers: map[string]string{
+				headerRateLimitRemaining: "10",
+			},
+			wantBucket:    true,
+			wantRemaining: 10,
+			wantReset:     rateLimitNow.Add(rateLimitWindow),
+		},
+		{
+			name:       "remaining 0",
+			statusCode: http.StatusOK,
+			headers: map[string]string{
+				headerRateLimitRemaining: "0",
+				headerRateLimitReset:     unixHeader(rateLimitNow.Add(20 * time.Second)),
+			},
+			wantBucket:    true,
+			wantRemaining: 0,
+			wantReset:     rateLimitNow.Add(20 * time.Second),
+		},
+		{
+			name:       "reset already passed",
+			statusCode: http.StatusOK,
+			headers: map[string]string{
+				headerRateLimitRemaining: "5",
+				headerRateLimitReset:     unixHeader(rateLimitNow.Add(-time.Minute)),
+			},
+			wantBucket:    true,
+			wantRemaining: 5,
+			wantReset:     rateLimitNow,
+		},
+		{
+			name:          "429 without headers",
+			statusCode:    http.StatusTooManyRequests,
+			wantBucket:    true,
+			wantRemaining: 0,
+			wantReset:     rateLimitNow.Add(rateLimitWindow),
+		},
+		{
+			name:       "429 with retry after ignores remaining",
+			statusCode: http.StatusTooManyRequests,
+			headers: map[string]string{
+				headerRateLimitRemaining: "3",
+				headerRetryAfter:         "5",
+			},
+			wantBucket:    true,
+			wantRemaining: 0,
+			wantReset:     rateLimitNow.Add(5 * time.Second),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter()
+			l.update("token", makeRateLimitResponse(tt.statusCode, tt.headers), rateLimitNow)
+
+			b, ok := l.buckets["token"]
+			if ok != tt.wantBucket {
+				t.Fatalf("bucket exists = %v, want %v", ok, tt.wantBucket)
+			}
+			if !ok {
+				return
+			}
+			if b.remaining != tt.wantRemaining {
+				t.Errorf("remaining = %d, want %d", b.remaining, tt.wantRemaining)
+			}
+			if !b.reset.Equal(tt.wantReset) {
+				t.Errorf("reset = %v, want %v", b.reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket *rateBucket
+		// waits are returned by consecutive reservations made at the same time
+		wantWaits []time.Duration
+	}{
+		{
+			name:      "unknown token",
+			wantWaits: []time.Duration{0, 0},
+		},
+		{
+			name:      "reset already passed",
+			bucket:    &rateBucket{remaining: 0, reset: rateLimitNow.Add(-time.Second)},
+			wantWaits: []time.Duration{0, 0},
+		},
+		{
+			name:      "reset right now",
+			bucket:    &rateBucket{remaining: 0, reset: rateLimitNow},
+			wantWaits: []time.Duration{0},
+		},
+		{
+			name:      "remaining 0 waits until reset",
+			bucket:    &rateBucket{remaining: 0, reset: rateLimitNow.Add(20 * time.Second)},
+			wantWaits: []time.Duration{20 * time.Second, 20 * time.Second},
+		},
+		{
+			name:   "remaining requests are spread until reset",
+			bucket: &rateBucket{remaining: 4, reset: rateLimitNow.Add(40 * time.Second)},
+			wantWaits: []time.Duration{
+				0,
+				10 * time.Second,
+				20 * time.Second,
+				30 * time.Second,
+				40 * time.Second,
+			},
+		},
+		{
+			name:      "next request is scheduled after the previous one",
+			bucket:    &rateBucket{remaining: 2, reset: rateLimitNow.Add(30 * time.Second), next: rateLimitNow.Add(10 * time.Second)},
+			wantWaits: []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter()
+			if tt.bucket != nil {
+				l.buckets["token"] = tt.bucket
+			}
+
+			for i, want := range tt.wantWaits {
+				if wait := l.reserve("token", rateLimitNow); wait != want {
+					t.Errorf("reservation %d waits %v, want %v", i, wait, want)
+				}
+			}
+		})
+	}
+}
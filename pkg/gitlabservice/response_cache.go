@@ -0,0 +1,219 @@
+package gitlabservice
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+// ResponseStore persists cached responses so that they survive restarts
+type ResponseStore interface {
+	Find(key string) (*config.GitlabResponse, error)
+	Save(response *config.GitlabResponse) error
+	DeleteUpdatedBefore(t time.Time) error
+}
+
+// ResponseCache keeps responses of gitlab API along with their ETags and revalidates them with conditional requests,
+// so that unchanged responses are not transferred again. Responses are kept in memory and in the store if it is set,
+// least recently used ones are evicted from memory once their bodies exceed maxBytes
+type ResponseCache struct {
+	// responses are elements of lru holding *config.GitlabResponse, the most recently used one is at the front
+	responses map[string]*list.Element
+	lru       *list.List
+	size      int64
+	maxBytes  int64
+	store     ResponseStore
+	mu        sync.Mutex
+	log.Loggable
+}
+
+func NewResponseCache(store ResponseStore, maxBytes int64) *ResponseCache {
+	return &ResponseCache{
+		responses: make(map[string]*list.Element),
+		lru:       list.New(),
+		maxBytes:  maxBytes,
+		store:     store,
+	}
+}
+
+// Transport wraps the transport to cache responses of GET requests sent through it
+func (c *ResponseCache) Transport(next http.RoundTripper) http.RoundTripper {
+	return &cachingTransport{cache: c, next: next}
+}
+
+// DeleteUpdatedBefore forgets responses which haven't changed since the time
+func (c *ResponseCache) DeleteUpdatedBefore(t time.Time) error {
+	c.mu.Lock()
+	for _, element := range c.responses {
+		if element.Value.(*config.GitlabResponse).UpdatedAt.Before(t) {
+			c.remove(element)
+		}
+	}
+	c.mu.Unlock()
+
+	if c.store == nil {
+		return nil
+	}
+	return c.store.DeleteUpdatedBefore(t)
+}
+
+func (c *ResponseCache) get(key string) *config.GitlabResponse {
+	c.mu.Lock()
+	element, ok := c.responses[key]
+	if ok {
+		c.lru.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if ok {
+		return element.Value.(*config.GitlabResponse)
+	}
+	if c.store == nil {
+		return nil
+	}
+
+	response, err := c.store.Find(key)
+	if err != nil {
+		c.Log().Warnf("Failed to find cached gitlab response: %v", err)
+		return nil
+	}
+	if response != nil {
+		c.mu.Lock()
+		c.put(response)
+		c.mu.Unlock()
+	}
+	return response
+}
+
+func (c *ResponseCache) save(response *config.GitlabResponse) {
+	c.mu.Lock()
+	c.put(response)
+	c.mu.Unlock()
+
+	if c.store == nil {
+		return
+	}
+	if err := c.store.Save(response); err != nil {
+		c.Log().Warnf("Failed to save cached gitlab response: %v", err)
+	}
+}
+
+// put keeps the response in memory evicting least recently used ones over the limit, the lock must be held
+func (c *ResponseCache) put(response *config.GitlabResponse) {
+	if element, ok := c.responses[response.Key]; ok {
+		c.remove(element)
+	}
+	if int64(len(response.Body)) > c.maxBytes {
+		return
+	}
+
+	c.responses[response.Key] = c.lru.PushFront(response)
+	c.size += int64(len(response.Body))
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove forgets the response in memory, the lock must be held
+func (c *ResponseCache) remove(element *list.Element) {
+	response := c.lru.Remove(element).(*config.GitlabResponse)
+	delete(c.responses, response.Key)
+	c.size -= int64(len(response.Body))
+}
+
+type cachingTransport struct {
+	cache *ResponseCache
+	next  http.RoundTripper
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := responseKey(req)
+	cached := t.cache.get(key)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		_ = resp.Body.Close()
+		return makeCachedResponse(req, cached, resp.Header)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || len(etag) == 0 {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %v", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return nil, fmt.Errorf("serialize response header: %v", err)
+	}
+
+	t.cache.save(&config.GitlabResponse{
+		Key:       key,
+		ETag:      etag,
+		Header:    string(header),
+		Body:      body,
+		UpdatedAt: time.Now().UTC(),
+	})
+
+	return resp, nil
+}
+
+// makeCachedResponse replays the cached response with headers of the fresh not modified response on top of the cached
+// ones, so that rate limit and pagination headers are up to date
+func makeCachedResponse(req *http.Request, cached *config.GitlabResponse, freshHeader http.Header) (*http.Response, error) {
+	var header http.Header
+	if err := json.Unmarshal([]byte(cached.Header), &header); err != nil {
+		return nil, fmt.Errorf("deserialize cached response header: %v", err)
+	}
+	for name, values := range freshHeader {
+		// the body is the cached one so its length is kept
+		if name != "Content-Length" {
+			header[name] = values
+		}
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}, nil
+}
+
+// responseKey identifies the request by its url and the token as users with different tokens may see different data
+func responseKey(req *http.Request) string {
+	hash := sha256.Sum256([]byte(req.Header.Get("Private-Token") + "\n" + req.Header.Get("Authorization") + "\n" + req.URL.String()))
+	return hex.EncodeToString(hash[:])
+}
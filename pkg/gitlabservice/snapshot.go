@@ -0,0 +1,204 @@
+package gitlabservice
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/workerpool"
+)
+
+// Snapshot is the state of opened merge requests of a group shared by all checks of a run,
+// details of merge requests are fetched on first use and only once however many checks use them.
+// Details are in order of MergeRequests and are nil for merge requests they failed to be fetched for
+// or which were left out by the predicate of every call so far
+type Snapshot struct {
+	GroupId       int
+	MergeRequests []*gitlab.MergeRequest
+
+	client *Client
+
+	discussionsOnce sync.Once
+	discussions     [][]*gitlab.Discussion
+	// details fetched only for merge requests checks need them for are guarded per merge request
+	participantsOnce []sync.Once
+	participants     [][]*gitlab.BasicUser
+	approvalsOnce    []sync.Once
+	approvals        []*gitlab.MergeRequestApprovals
+	statesOnce       []sync.Once
+	states           []*gitlab.MergeRequestApprovalState
+
+	// errs are failures of requests whose data is missing in the snapshot,
+	// snapshots made by Filter record them in the root snapshot they are made of
+	errs []error
+	root *Snapshot
+	mu   sync.Mutex
+}
+
+// Snapshot fetches opened merge requests of the group, merge requests which failed to be fetched are left out
+func (client *Client) Snapshot(ctx context.Context, groupId int) *Snapshot {
+	s := &Snapshot{GroupId: groupId, client: client}
+
+	mrs, err := client.mergeRequests.ListOpenedGroupMergeRequests(ctx, groupId)
+	if err != nil {
+		s.fail(err)
+		return s
+	}
+
+	s.load(ctx, mrs)
+	return s
+}
+
+// MergeRequestsSnapshot fetches the given merge requests of the group without listing the rest of it,
+// merge requests which are not opened anymore or failed to be fetched are left out
+func (client *Client) MergeRequestsSnapshot(ctx context.Context, groupId int, mrs []*gitlab.MergeRequest) *Snapshot {
+	s := &Snapshot{GroupId: groupId, client: client}
+	s.load(ctx, mrs)
+	return s
+}
+
+// load fetches changes of the merge requests and keeps opened ones
+func (s *Snapshot) load(ctx context.Context, mrs []*gitlab.MergeRequest) {
+	fullMrs := make([]*gitlab.MergeRequest, len(mrs))
+	s.fetch(ctx, len(mrs), func(ctx context.Context, i int) (err error) {
+		fullMrs[i], err = s.client.mergeRequests.GetMergeRequestChanges(ctx, mrs[i])
+		return err
+	})
+
+	s.MergeRequests = make([]*gitlab.MergeRequest, 0, len(fullMrs))
+	for _, mr := range fullMrs {
+		if mr != nil && mr.State == "opened" {
+			s.MergeRequests = append(s.MergeRequests, mr)
+		}
+	}
+	s.allocate()
+}
+
+// Filter returns the snapshot of merge requests matching include whose details are fetched anew,
+// the snapshot itself is returned if all of them match. Failures of the returned snapshot are recorded in this one
+func (s *Snapshot) Filter(include predicate) *Snapshot {
+	mrs := make([]*gitlab.MergeRequest, 0, len(s.MergeRequests))
+	for _, mr := range s.MergeRequests {
+		if include(mr) {
+			mrs = append(mrs, mr)
+		}
+	}
+	if len(mrs) == len(s.MergeRequests) {
+		return s
+	}
+
+	root := s
+	if s.root != nil {
+		root = s.root
+	}
+	filtered := &Snapshot{GroupId: s.GroupId, MergeRequests: mrs, client: s.client, root: root}
+	filtered.allocate()
+	return filtered
+}
+
+// allocate makes room for details of the merge requests
+func (s *Snapshot) allocate() {
+	s.participantsOnce = make([]sync.Once, len(s.MergeRequests))
+	s.participants = make([][]*gitlab.BasicUser, len(s.MergeRequests))
+	s.approvalsOnce = make([]sync.Once, len(s.MergeRequests))
+	s.approvals = make([]*gitlab.MergeRequestApprovals, len(s.MergeRequests))
+	s.statesOnce = make([]sync.Once, len(s.MergeRequests))
+	s.states = make([]*gitlab.MergeRequestApprovalState, len(s.MergeRequests))
+}
+
+// Participants returns participants of each merge request except its author,
+// they are fetched only for merge requests matching include
+func (s *Snapshot) Participants(ctx context.Context, include predicate) [][]*gitlab.BasicUser {
+	s.fetchIncluded(ctx, s.participantsOnce, include, func(ctx context.Context, i int) (err error) {
+		s.participants[i], err = s.client.mergeRequests.GetMergeRequestsParticipants(ctx, s.MergeRequests[i])
+		return err
+	})
+	return s.participants
+}
+
+// Discussions returns all discussions of each merge request
+func (s *Snapshot) Discussions(ctx context.Context) [][]*gitlab.Discussion {
+	s.discussionsOnce.Do(func() {
+		s.discussions = make([][]*gitlab.Discussion, len(s.MergeRequests))
+		s.fetch(ctx, len(s.MergeRequests), func(ctx context.Context, i int) (err error) {
+			s.discussions[i], err = s.client.discussions.GetMergeRequestDiscussions(ctx, s.MergeRequests[i])
+			return err
+		})
+	})
+	return s.discussions
+}
+
+// Approvals returns approvals of each merge request, they are fetched only for merge requests matching include
+func (s *Snapshot) Approvals(ctx context.Context, include predicate) []*gitlab.MergeRequestApprovals {
+	s.fetchIncluded(ctx, s.approvalsOnce, include, func(ctx context.Context, i int) (err error) {
+		s.approvals[i], err = s.client.mergeRequests.GetMergeRequestApprovals(ctx, s.MergeRequests[i])
+		return err
+	})
+	return s.approvals
+}
+
+// ApprovalStates returns approval rules of each merge request, they are fetched only for merge requests matching include
+func (s *Snapshot) ApprovalStates(ctx context.Context, include predicate) []*gitlab.MergeRequestApprovalState {
+	s.fetchIncluded(ctx, s.statesOnce, include, func(ctx context.Context, i int) (err error) {
+		s.states[i], err = s.client.mergeRequests.GetMergeRequestApprovalState(ctx, s.MergeRequests[i])
+		return err
+	})
+	return s.states
+}
+
+// Errors returns failures of requests whose data is missing in the snapshot, empty if the snapshot is complete
+func (s *Snapshot) Errors() []error {
+	if s.root != nil {
+		return s.root.Errors()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]error(nil), s.errs...)
+}
+
+// fetch calls fetchOne for each index concurrently and records its failures
+// along with the interruption of the context which leaves the rest unfetched
+func (s *Snapshot) fetch(ctx context.Context, count int, fetchOne func(ctx context.Context, i int) error) {
+	workerpool.Run(ctx, s.client.concurrency, count, func(ctx context.Context, i int) {
+		if err := fetchOne(ctx, i); err != nil {
+			s.fail(err)
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		s.fail(fmt.Errorf("fetch merge requests of group %d: %w", s.GroupId, err))
+	}
+}
+
+// fetchIncluded fetches details of merge requests matching include which were not fetched by previous calls
+func (s *Snapshot) fetchIncluded(ctx context.Context, once []sync.Once, include predicate, fetchOne func(ctx context.Context, i int) error) {
+	included := make([]int, 0, len(s.MergeRequests))
+	for i, mr := range s.MergeRequests {
+		if include(mr) {
+			included = append(included, i)
+		}
+	}
+
+	s.fetch(ctx, len(included), func(ctx context.Context, j int) (err error) {
+		i := included[j]
+		once[i].Do(func() {
+			err = fetchOne(ctx, i)
+		})
+		return err
+	})
+}
+
+func (s *Snapshot) fail(err error) {
+	if s.root != nil {
+		s.root.fail(err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errs = append(s.errs, err)
+}
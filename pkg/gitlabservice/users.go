@@ -0,0 +1,59 @@
+package gitlabservice
+
+import (
+	"sync"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+type UsersService struct {
+	client *gitlab.Client
+	// public emails by user id, users rarely change them so it is cached for the client lifetime
+	emails map[int]*publicEmail
+	mu     sync.Mutex
+	log.Loggable
+}
+
+// publicEmail is fetched once however many callers ask for it at once
+type publicEmail struct {
+	once  sync.Once
+	email string
+	ok    bool
+}
+
+func NewUsersService(client *gitlab.Client) *UsersService {
+	return &UsersService{client: client, emails: make(map[int]*publicEmail)}
+}
+
+// GetPublicEmail returns the public email of the user or empty string if user doesn't have it
+func (service *UsersService) GetPublicEmail(userId int) string {
+	service.mu.Lock()
+	entry, ok := service.emails[userId]
+	if !ok {
+		entry = &publicEmail{}
+		service.emails[userId] = entry
+	}
+	service.mu.Unlock()
+
+	entry.once.Do(func() {
+		user, _, err := service.client.Users.GetUser(userId)
+		if err != nil {
+			service.Log().Warnf("Failed to get user %d: %v", userId, err)
+			return
+		}
+		entry.email = user.PublicEmail
+		entry.ok = true
+	})
+
+	if !entry.ok {
+		// failures are not cached so that the next call tries again
+		service.mu.Lock()
+		if service.emails[userId] == entry {
+			delete(service.emails, userId)
+		}
+		service.mu.Unlock()
+	}
+	return entry.email
+}
@@ -0,0 +1,175 @@
+package interaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+// Actions of alert buttons
+const (
+	ActionAck            = "ack"
+	ActionSnooze         = "snooze"
+	ActionSnoozeTomorrow = "tomorrow"
+	ActionIgnore         = config.AckActionIgnore
+)
+
+const (
+	// ackDuration is how long alerts about an item are skipped once somebody is on it
+	ackDuration    = 24 * time.Hour
+	snoozeDuration = 4 * time.Hour
+	// buttonLifetime is how long buttons of an alert may be clicked, values of older ones can't be replayed
+	buttonLifetime = 7 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidValue = errors.New("invalid button value")
+	ErrExpiredValue = errors.New("button value expired")
+)
+
+// Item is what an alert is about, empty DiscussionId means the merge request itself,
+// Kind is the notification kind of the alert as alerts of different kinds may be about the same merge request
+type Item struct {
+	ClientId        int
+	ProjectId       int
+	MergeRequestIid int
+	DiscussionId    string
+	Kind            string
+}
+
+// Buttons makes buttons of alerts and parses values of the clicked ones,
+// values are signed with the secret so that callbacks can't be forged
+type Buttons struct {
+	// mattermostCallbackUrl is where mattermost sends clicks to, slack sends them to the request url of the app
+	mattermostCallbackUrl string
+	secret                []byte
+}
+
+func NewButtons(callbackBaseUrl string, secret string) *Buttons {
+	return &Buttons{
+		mattermostCallbackUrl: strings.TrimSuffix(callbackBaseUrl, "/") + "/interactions/mattermost",
+		secret:                []byte(secret),
+	}
+}
+
+func (b *Buttons) Make(item Item) []webhook.Button {
+	expiresAt := time.Now().UTC().Add(buttonLifetime).Unix()
+	buttons := []webhook.Button{
+		{Action: ActionAck, Label: "I'm on it"},
+		{Action: ActionSnooze, Label: "Snooze 4h"},
+		{Action: ActionSnoozeTomorrow, Label: "Snooze until tomorrow"},
+		{Action: ActionIgnore, Label: "Ignore this MR"},
+	}
+	for i := range buttons {
+		buttons[i].Value = b.sign(buttons[i].Action, item, expiresAt)
+		buttons[i].CallbackUrl = b.mattermostCallbackUrl
+	}
+	return buttons
+}
+
+// Parse returns the action and the item of the button value if its signature is valid and it hasn't expired at the time
+func (b *Buttons) Parse(value string, now time.Time) (string, Item, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 8 {
+		return "", Item{}, ErrInvalidValue
+	}
+
+	action, item := parts[0], parseItem(parts[1:6])
+	expiresAt, err := strconv.ParseInt(parts[6], 10, 64)
+	if err != nil {
+		return "", Item{}, ErrInvalidValue
+	}
+	if !hmac.Equal([]byte(b.sign(action, item, expiresAt)), []byte(value)) {
+		return "", Item{}, ErrInvalidValue
+	}
+	if !now.Before(time.Unix(expiresAt, 0)) {
+		return "", Item{}, ErrExpiredValue
+	}
+
+	return action, item, nil
+}
+
+// sign makes the value of the button in form of action:client:project:merge request:discussion:kind:expiration:signature,
+// expiration is unix time the value may be used until
+func (b *Buttons) sign(action string, item Item, expiresAt int64) string {
+	payload := fmt.Sprintf("%s:%d:%d:%d:%s:%s:%d", action, item.ClientId, item.ProjectId, item.MergeRequestIid, item.DiscussionId, item.Kind, expiresAt)
+	mac := hmac.New(sha256.New, b.secret)
+	mac.Write([]byte(payload))
+	return payload + ":" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func parseItem(parts []string) Item {
+	clientId, _ := strconv.Atoi(parts[0])
+	projectId, _ := strconv.Atoi(parts[1])
+	mergeRequestIid, _ := strconv.Atoi(parts[2])
+	return Item{
+		ClientId:        clientId,
+		ProjectId:       projectId,
+		MergeRequestIid: mergeRequestIid,
+		DiscussionId:    parts[3],
+		Kind:            parts[4],
+	}
+}
+
+// NewAcknowledgement makes the acknowledgement of the item by the user,
+// tomorrow is the moment alerts snoozed until tomorrow are resumed at
+func NewAcknowledgement(action string, item Item, userName string, now time.Time, tomorrow time.Time) (*config.Acknowledgement, error) {
+	ack := &config.Acknowledgement{
+		ClientId:        item.ClientId,
+		ProjectId:       item.ProjectId,
+		MergeRequestIid: item.MergeRequestIid,
+		DiscussionId:    item.DiscussionId,
+		Kind:            item.Kind,
+		Action:          action,
+		UserName:        userName,
+		CreatedAt:       now,
+	}
+
+	var until time.Time
+	switch action {
+	case ActionAck:
+		until = now.Add(ackDuration)
+	case ActionSnooze:
+		until = now.Add(snoozeDuration)
+	case ActionSnoozeTomorrow:
+		until = tomorrow
+	case ActionIgnore:
+		// the whole merge request is ignored whichever of its alerts was clicked
+		ack.DiscussionId = ""
+		ack.Kind = config.AckKindAll
+		return ack, nil
+	default:
+		return nil, fmt.Errorf("unknown action %s", action)
+	}
+	ack.Until = &until
+
+	return ack, nil
+}
+
+// Status describes the acknowledgement to show it on the alert
+func Status(ack *config.Acknowledgement, location *time.Location) string {
+	// users who couldn't be resolved by their chat ids are not named
+	user := "somebody"
+	if len(ack.UserName) > 0 {
+		user = "@" + ack.UserName
+	}
+	switch ack.Action {
+	case ActionAck:
+		if len(ack.UserName) == 0 {
+			return ":eyes: Somebody is on it"
+		}
+		return fmt.Sprintf(":eyes: %s is on it", user)
+	case ActionIgnore:
+		return fmt.Sprintf(":no_bell: Ignored by %s", user)
+	default:
+		return fmt.Sprintf(":zzz: Snoozed by %s until %s", user, ack.Until.In(location).Format("Jan 2 15:04"))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -59,12 +60,17 @@ func NewLogger() *Logger {
 	return newLogger(2)
 }
 
+// configureOnce sets field names and time format of zerolog which are global
+var configureOnce sync.Once
+
 func newLogger(deep int) *Logger {
-	zerolog.TimestampFieldName = "@timestamp"
-	zerolog.LevelFieldName = "level"
-	zerolog.MessageFieldName = "message"
-	zerolog.ErrorFieldName = "stack_trace"
-	zerolog.TimeFieldFormat = time.RFC3339Nano
+	configureOnce.Do(func() {
+		zerolog.TimestampFieldName = "@timestamp"
+		zerolog.LevelFieldName = "level"
+		zerolog.MessageFieldName = "message"
+		zerolog.ErrorFieldName = "stack_trace"
+		zerolog.TimeFieldFormat = time.RFC3339Nano
+	})
 	programCounter, file, _, _ := runtime.Caller(deep)
 	funcName := runtime.FuncForPC(programCounter).Name()
 	_, fileName := path.Split(file)
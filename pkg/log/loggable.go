@@ -1,12 +1,17 @@
 package log
 
+import "sync"
+
+// Loggable makes the logger on first use, it is safe to use from several goroutines
 type Loggable struct {
 	logger *Logger
+	once   sync.Once
 }
 
 func (l *Loggable) Log() *Logger {
-	if l.logger == nil {
-		l.logger = newLogger(2)
-	}
+	l.once.Do(func() {
+		// skipping this function, sync.Once frames and Log itself to name the logger after the caller of Log
+		l.logger = newLogger(5)
+	})
 	return l.logger
 }
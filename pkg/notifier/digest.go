@@ -0,0 +1,306 @@
+package notifier
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/gitlabservice"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+const digestTemplateFileName = "digest.gotpl"
+
+// kindDigest is a kind of the message grouping notifications of all kinds
+const kindDigest = "digest"
+
+// digestSectionTitles are titles of digest sections in the order they appear in the digest
+var digestSectionTitles = []struct {
+	kind  string
+	title string
+}{
+	{kind: config.KindFiringDiscussion, title: "Discussions waiting for reviewers"},
+	{kind: config.KindNeededReviewMergeRequest, title: "Merge requests needing review"},
+	{kind: config.KindOldMergeRequest, title: "Stale merge requests"},
+}
+
+// Digest is everything found for a client in a single run to notify about with one grouped message
+type Digest struct {
+	OldMergeRequests          []*gitlab.MergeRequest
+	NeededReviewMergeRequests []*gitlabservice.MergeRequestWithParticipants
+	// FiringMergeRequests contain only discussions to notify about
+	FiringMergeRequests []gitlabservice.FiringMergeRequest
+	// Incomplete tells that some merge requests failed to be fetched from gitlab
+	Incomplete bool
+}
+
+func (d Digest) IsEmpty() bool {
+	return len(d.OldMergeRequests) == 0 && len(d.NeededReviewMergeRequests) == 0 && len(d.FiringMergeRequests) == 0
+}
+
+// DigestMessage is a data passed to the digest template
+type DigestMessage struct {
+	Sections                  []DigestSection
+	MergeRequestOldMention    string
+	MergeRequestReviewMention string
+	// Total is a number of all notifications in the digest including omitted ones
+	Total int
+	// Incomplete tells that some merge requests failed to be fetched from gitlab
+	Incomplete bool
+	messages   []message
+}
+
+// DigestSection groups notifications of one kind by projects
+type DigestSection struct {
+	Kind     string
+	Title    string
+	Projects []DigestProject
+	Total    int
+}
+
+// DigestProject contains notifications about merge requests of one project starting from the oldest
+type DigestProject struct {
+	ProjectId int
+	Path      string
+	// Messages are OldMergeRequestMessage, NeededReviewMergeRequestMessage or DiscussionMessage
+	// according to the kind of the section
+	Messages []interface{}
+	// More is a number of notifications omitted to fit the message into the webhook limit
+	More int
+}
+
+func (n *Notifier) NotifyDigest(digest Digest, config *config.FiringConfig) error {
+	messages := makeDigestMessages(digest, config)
+	if len(messages) == 0 {
+		return nil
+	}
+
+	routed := make([][]message, len(n.routes))
+	unrouted := make([]message, 0)
+	for _, m := range messages {
+		accepted := false
+		for i, route := range n.routes {
+			if route.accepts(m.kind(), m.projectId()) {
+				routed[i] = append(routed[i], m)
+				accepted = true
+			}
+		}
+		if !accepted {
+			unrouted = append(unrouted, m)
+		}
+	}
+
+	var errs []error
+	for i, route := range n.routes {
+		if len(routed[i]) == 0 {
+			continue
+		}
+		if err := n.sendDigest(route.Webhook, route.TargetId, routed[i], digest.Incomplete, config); err != nil {
+			errs = append(errs, fmt.Errorf("notify digest to target %s: %w", route.Name, err))
+		}
+	}
+
+	if len(unrouted) > 0 {
+		if err := n.sendDigest(n.webhook, 0, unrouted, digest.Incomplete, config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+// sendDigest halves the number of notifications shown per project until the digest fits the webhook limit
+func (n *Notifier) sendDigest(hook webhook.Webhook, targetId int, messages []message, incomplete bool, config *config.FiringConfig) error {
+	maxLength := 0
+	if limitedHook, ok := hook.(webhook.LimitedWebhook); ok {
+		maxLength = limitedHook.MaxTextLength()
+	}
+
+	for perProject := len(messages); ; perProject /= 2 {
+		data := newDigestMessage(messages, perProject, config)
+		data.Incomplete = incomplete
+		text, err := n.renderTemplate(data, digestTemplateFileName)
+		if err != nil {
+			return err
+		}
+		if maxLength == 0 || len([]rune(text)) <= maxLength || perProject <= 1 {
+			return n.send(hook, targetId, data, digestTemplateFileName, truncateText(text, maxLength))
+		}
+	}
+}
+
+func makeDigestMessages(digest Digest, config *config.FiringConfig) []message {
+	messages := make([]message, 0)
+	for _, mr := range digest.OldMergeRequests {
+		messages = append(messages, NewOldMergeRequestMessage(mr, config))
+	}
+	for _, mrp := range digest.NeededReviewMergeRequests {
+		messages = append(messages, NewNeededReviewMergeRequestMessage(mrp, config))
+	}
+	for _, fmr := range digest.FiringMergeRequests {
+		for _, m := range MakeDiscussionMessages(fmr) {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// newDigestMessage groups messages by kinds and projects showing at most perProject oldest messages of each project
+func newDigestMessage(messages []message, perProject int, config *config.FiringConfig) DigestMessage {
+	sorted := append([]message{}, messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].age() > sorted[j].age()
+	})
+
+	sections := make([]DigestSection, 0, len(digestSectionTitles))
+	for _, sectionTitle := range digestSectionTitles {
+		section := DigestSection{Kind: sectionTitle.kind, Title: sectionTitle.title}
+		// projects go in the order of their oldest messages
+		projectIndexes := make(map[int]int)
+		for _, m := range sorted {
+			if m.kind() != section.Kind {
+				continue
+			}
+			index, ok := projectIndexes[m.projectId()]
+			if !ok {
+				index = len(section.Projects)
+				projectIndexes[m.projectId()] = index
+				section.Projects = append(section.Projects, DigestProject{
+					ProjectId: m.projectId(),
+					Path:      projectPath(m),
+				})
+			}
+			project := &section.Projects[index]
+			if len(project.Messages) < perProject {
+				project.Messages = append(project.Messages, m)
+			} else {
+				project.More++
+			}
+			section.Total++
+		}
+		if section.Total > 0 {
+			sections = append(sections, section)
+		}
+	}
+
+	return DigestMessage{
+		Sections:                  sections,
+		MergeRequestOldMention:    config.MergeRequestOldMention,
+		MergeRequestReviewMention: config.MergeRequestReviewMention,
+		Total:                     len(messages),
+		messages:                  sorted,
+	}
+}
+
+func (m DigestMessage) makeCard(text string) webhook.Card {
+	facts := make([]webhook.Fact, 0, len(m.Sections))
+	for _, section := range m.Sections {
+		facts = append(facts, webhook.Fact{Name: section.Title, Value: fmt.Sprint(section.Total)})
+	}
+	return webhook.Card{
+		Title: fmt.Sprintf("Code review digest: %d notifications", m.Total),
+		Text:  text,
+		Facts: facts,
+	}
+}
+
+func (m DigestMessage) makeEvent(text string) webhook.Event {
+	items := make([]webhook.Event, 0, len(m.messages))
+	for _, item := range m.messages {
+		items = append(items, item.makeEvent(""))
+	}
+	return webhook.Event{
+		Version:      webhook.EventVersion,
+		Type:         webhook.EventDigest,
+		SentAt:       time.Now().UTC(),
+		Text:         text,
+		Participants: []webhook.EventUser{},
+		Items:        items,
+	}
+}
+
+func (m DigestMessage) users() []*gitlab.BasicUser {
+	users := make([]*gitlab.BasicUser, 0)
+	for _, item := range m.messages {
+		users = append(users, item.users()...)
+	}
+	return users
+}
+
+// responsibles returns no users as the digest is about everyone and is sent to the channel
+func (m DigestMessage) responsibles() []*gitlab.BasicUser {
+	return nil
+}
+
+func (m DigestMessage) kind() string {
+	return kindDigest
+}
+
+func (m DigestMessage) projectId() int {
+	return 0
+}
+
+func (m DigestMessage) mergeRequestIid() int {
+	return 0
+}
+
+func (m DigestMessage) discussionId() string {
+	return ""
+}
+
+func (m DigestMessage) color() string {
+	return ""
+}
+
+func (m DigestMessage) age() time.Duration {
+	if len(m.messages) == 0 {
+		return 0
+	}
+	return m.messages[0].age()
+}
+
+// projectPath returns the path of the project of the merge request the message is about
+func projectPath(m message) string {
+	var mr *gitlab.MergeRequest
+	switch m := m.(type) {
+	case OldMergeRequestMessage:
+		mr = m.MergeRequest
+	case NeededReviewMergeRequestMessage:
+		mr = m.MergeRequest
+	case DiscussionMessage:
+		mr = &m.MergeRequest
+	}
+
+	if mr != nil {
+		if u, err := url.Parse(mr.WebURL); err == nil {
+			path := strings.TrimPrefix(u.Path, "/")
+			if i := strings.Index(path, "/-/merge_requests/"); i > 0 {
+				return path[:i]
+			}
+			if i := strings.Index(path, "/merge_requests/"); i > 0 {
+				return path[:i]
+			}
+		}
+	}
+
+	return fmt.Sprintf("project %d", m.projectId())
+}
+
+// truncateText cuts the text at the last line fitting the max length if it is set
+func truncateText(text string, maxLength int) string {
+	runes := []rune(text)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return text
+	}
+
+	truncated := string(runes[:maxLength-2])
+	if i := strings.LastIndex(truncated, "\n"); i > 0 {
+		truncated = truncated[:i]
+	}
+	return truncated + "\n…"
+}
@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+// sendDirect sends the message to each responsible user directly
+// and to the channel if some of them can't be found in the chat or there are no responsible users at all
+func (n *Notifier) sendDirect(hook webhook.DirectWebhook, data message, text string) error {
+	users := uniqueUsers(data.responsibles())
+	needChannel := len(users) == 0
+
+	for _, user := range users {
+		chatUserId, err := n.findChatUser(hook, user)
+		if err == webhook.ErrUserNotFound {
+			n.Log().Debugf("No chat user for gitlab user %s, falling back to the channel", user.Username)
+			needChannel = true
+			continue
+		}
+		if err != nil {
+			n.Log().Warnf("Failed to find chat user for gitlab user %s: %v", user.Username, err)
+			needChannel = true
+			continue
+		}
+		if err := hook.SendDirect(chatUserId, text); err != nil {
+			n.Log().Warnf("Failed to send direct message to gitlab user %s: %v", user.Username, err)
+			needChannel = true
+		}
+	}
+
+	if !needChannel {
+		return nil
+	}
+
+	if err := hook.Send(text); err != nil {
+		return fmt.Errorf("send webhook message: %v", err)
+	}
+
+	return nil
+}
+
+func (n *Notifier) findChatUser(hook webhook.DirectWebhook, user *gitlab.BasicUser) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	users, ok := n.chatUsers[hook]
+	if !ok {
+		users = make(map[int]string)
+		n.chatUsers[hook] = users
+	}
+	if chatUserId, ok := users[user.ID]; ok {
+		if len(chatUserId) == 0 {
+			return "", webhook.ErrUserNotFound
+		}
+		return chatUserId, nil
+	}
+
+	username := user.Username
+	if mapping := n.userMappings.find(user); mapping != nil {
+		if len(mapping.ChatUserId) > 0 {
+			users[user.ID] = mapping.ChatUserId
+			return mapping.ChatUserId, nil
+		}
+		username = mapping.ChatUsername
+	}
+
+	email := ""
+	if n.emails != nil {
+		email = n.emails.GetPublicEmail(user.ID)
+	}
+
+	chatUserId, err := hook.FindUser(username, email)
+	if err == webhook.ErrUserNotFound {
+		// remember the user is missing to not look it up again
+		users[user.ID] = ""
+	}
+	if err != nil {
+		return "", err
+	}
+
+	users[user.ID] = chatUserId
+	return chatUserId, nil
+}
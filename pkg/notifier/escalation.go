@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+// mentionReviewers in a mention of an escalation step is replaced with mentions of the merge request assignees
+const mentionReviewers = "@reviewers"
+
+// NotifyEscalatedMergeRequest notifies about the old merge request with the mention, the color and the target of the step
+func (n *Notifier) NotifyEscalatedMergeRequest(mr *gitlab.MergeRequest, config *config.FiringConfig, step *config.EscalationStep) error {
+	templateFileName := "old_merge_request.gotpl"
+	data := NewOldMergeRequestMessage(mr, config)
+	data.MergeRequestOldMention = n.expandMention(step.Mention, mr)
+	data.Color = step.Color
+
+	if step.TargetId == 0 {
+		return n.notifyMessage(data, templateFileName)
+	}
+
+	hook := n.targetWebhook(step.TargetId)
+	if hook == nil {
+		return fmt.Errorf("target %d of escalation step %d not found", step.TargetId, step.Id)
+	}
+
+	text, err := n.renderTemplate(data, templateFileName)
+	if err != nil {
+		return err
+	}
+
+	return n.send(hook, step.TargetId, data, templateFileName, text)
+}
+
+// expandMention replaces mentionReviewers with chat mentions of the merge request assignees
+// or of its author if nobody is assigned
+func (n *Notifier) expandMention(mention string, mr *gitlab.MergeRequest) string {
+	if !strings.Contains(mention, mentionReviewers) {
+		return mention
+	}
+
+	reviewers := uniqueUsers(append([]*gitlab.BasicUser{mr.Assignee}, mr.Assignees...))
+	if len(reviewers) == 0 && mr.Author != nil {
+		reviewers = []*gitlab.BasicUser{mr.Author}
+	}
+
+	mentions := make([]string, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		mentions = append(mentions, n.userMappings.chatMention(reviewer))
+	}
+
+	return strings.Replace(mention, mentionReviewers, strings.Join(mentions, " "), -1)
+}
@@ -10,9 +10,6 @@ func NewFactory(templatesBaseDir string) *Factory {
 	return &Factory{templatesBaseDir: templatesBaseDir}
 }
 
-func (f Factory) MakeWebhookNotifier(config webhook.MattermostConfig) *Notifier {
-	return NewNotifier(
-		webhook.NewMattermost(config),
-		"pkg/notifier/templates",
-	)
+func (f Factory) MakeWebhookNotifier(hook webhook.Webhook, options Options) *Notifier {
+	return NewNotifier(hook, f.templatesBaseDir, options)
 }
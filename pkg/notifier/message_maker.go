@@ -1,6 +1,8 @@
 package notifier
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hako/durafmt"
@@ -8,8 +10,31 @@ import (
 
 	"gitlab-code-review-notifier/pkg/config"
 	"gitlab-code-review-notifier/pkg/gitlabservice"
+	"gitlab-code-review-notifier/pkg/webhook"
 )
 
+// message is a data passed to a message template
+type message interface {
+	// makeCard makes a card with the rendered template text for webhooks able to send cards
+	makeCard(text string) webhook.Card
+	// makeEvent makes a structured event for webhooks sending data instead of a text
+	makeEvent(text string) webhook.Event
+	// users returns gitlab users the message is addressed to
+	users() []*gitlab.BasicUser
+	// responsibles returns gitlab users who are expected to act on the message
+	responsibles() []*gitlab.BasicUser
+	// kind returns the notification kind of the message e.g. config.KindOldMergeRequest
+	kind() string
+	projectId() int
+	mergeRequestIid() int
+	// discussionId returns the id of the discussion the message is about or empty string for merge request kinds
+	discussionId() string
+	// age returns how long the subject of the message is waiting for actions
+	age() time.Duration
+	// color returns the color to highlight the message with or empty string for the webhook default
+	color() string
+}
+
 type DiscussionMessage struct {
 	MergeRequest  gitlab.MergeRequest
 	Discussion    gitlab.Discussion
@@ -33,6 +58,78 @@ func NewDiscussionMessage(mergeRequest gitlab.MergeRequest, discussion gitlab.Di
 	}
 }
 
+func (m DiscussionMessage) makeCard(text string) webhook.Card {
+	return webhook.Card{
+		Title:     fmt.Sprintf("Discussion in merge request %s", m.MergeRequest.Reference),
+		TitleLink: fmt.Sprintf("%s#note_%d", m.MergeRequest.WebURL, m.LastNote.ID),
+		Text:      text,
+		Facts: []webhook.Fact{
+			{Name: "Merge request", Value: m.MergeRequest.Title},
+			{Name: "Reference", Value: m.MergeRequest.Reference},
+			{Name: "Waiting for", Value: m.TimePassedStr},
+			{Name: "Participants", Value: joinUsernames(m.Participants)},
+		},
+	}
+}
+
+func (m DiscussionMessage) makeEvent(text string) webhook.Event {
+	participants := make([]*gitlab.BasicUser, 0, len(m.Participants))
+	for i := range m.Participants {
+		participants = append(participants, &m.Participants[i])
+	}
+
+	event := makeMergeRequestEvent(webhook.EventFiringDiscussion, &m.MergeRequest, participants, text)
+	event.Discussion = &webhook.EventDiscussion{
+		Id:                m.Discussion.ID,
+		WebUrl:            fmt.Sprintf("%s#note_%d", m.MergeRequest.WebURL, m.LastNote.ID),
+		LastNoteId:        m.LastNote.ID,
+		LastNoteAuthor:    makeEventUser(m.LastNote.Author.ID, m.LastNote.Author.Username, m.LastNote.Author.Name),
+		LastNoteCreatedAt: m.LastNote.CreatedAt,
+	}
+	event.Timings.SinceLastNote = int64(m.TimePassed.Seconds())
+	return event
+}
+
+func (m DiscussionMessage) kind() string {
+	return config.KindFiringDiscussion
+}
+
+func (m DiscussionMessage) projectId() int {
+	return m.MergeRequest.ProjectID
+}
+
+func (m DiscussionMessage) mergeRequestIid() int {
+	return m.MergeRequest.IID
+}
+
+func (m DiscussionMessage) discussionId() string {
+	return m.Discussion.ID
+}
+
+func (m DiscussionMessage) age() time.Duration {
+	return m.TimePassed
+}
+
+func (m DiscussionMessage) color() string {
+	return ""
+}
+
+func (m DiscussionMessage) users() []*gitlab.BasicUser {
+	users := []*gitlab.BasicUser{m.MergeRequest.Author}
+	for i := range m.Participants {
+		users = append(users, &m.Participants[i])
+	}
+	return users
+}
+
+func (m DiscussionMessage) responsibles() []*gitlab.BasicUser {
+	users := make([]*gitlab.BasicUser, 0, len(m.Participants))
+	for i := range m.Participants {
+		users = append(users, &m.Participants[i])
+	}
+	return users
+}
+
 func MakeDiscussionMessages(fmr gitlabservice.FiringMergeRequest) []DiscussionMessage {
 	messages := make([]DiscussionMessage, 0)
 	for _, discussion := range fmr.FiringDiscussions {
@@ -44,9 +141,11 @@ func MakeDiscussionMessages(fmr gitlabservice.FiringMergeRequest) []DiscussionMe
 type OldMergeRequestMessage struct {
 	MergeRequest           *gitlab.MergeRequest
 	MergeRequestOldMention string
-	TimePassed             time.Duration
-	TimeSinceCreatedStr    string
-	TimeSinceUpdatedStr    string
+	// Color is set by escalation steps
+	Color               string
+	TimePassed          time.Duration
+	TimeSinceCreatedStr string
+	TimeSinceUpdatedStr string
 }
 
 func NewOldMergeRequestMessage(mergeRequest *gitlab.MergeRequest, config *config.FiringConfig) OldMergeRequestMessage {
@@ -61,9 +160,62 @@ func NewOldMergeRequestMessage(mergeRequest *gitlab.MergeRequest, config *config
 	}
 }
 
+func (m OldMergeRequestMessage) makeCard(text string) webhook.Card {
+	card := makeMergeRequestCard(m.MergeRequest, text)
+	card.Color = m.Color
+	card.Facts = append(card.Facts,
+		webhook.Fact{Name: "Created", Value: m.TimeSinceCreatedStr + " ago"},
+		webhook.Fact{Name: "Last updated", Value: m.TimeSinceUpdatedStr + " ago"},
+	)
+	return card
+}
+
+func (m OldMergeRequestMessage) makeEvent(text string) webhook.Event {
+	event := makeMergeRequestEvent(webhook.EventOldMergeRequest, m.MergeRequest, nil, text)
+	event.Mention = m.MergeRequestOldMention
+	return event
+}
+
+func (m OldMergeRequestMessage) kind() string {
+	return config.KindOldMergeRequest
+}
+
+func (m OldMergeRequestMessage) projectId() int {
+	return m.MergeRequest.ProjectID
+}
+
+func (m OldMergeRequestMessage) mergeRequestIid() int {
+	return m.MergeRequest.IID
+}
+
+func (m OldMergeRequestMessage) discussionId() string {
+	return ""
+}
+
+func (m OldMergeRequestMessage) age() time.Duration {
+	// it is assumed that go-gitlab package returns timestamps in UTC
+	return time.Now().UTC().Sub(*m.MergeRequest.UpdatedAt)
+}
+
+func (m OldMergeRequestMessage) color() string {
+	return m.Color
+}
+
+func (m OldMergeRequestMessage) users() []*gitlab.BasicUser {
+	return mergeRequestUsers(m.MergeRequest)
+}
+
+func (m OldMergeRequestMessage) responsibles() []*gitlab.BasicUser {
+	return mergeRequestUsers(m.MergeRequest)
+}
+
 type NeededReviewMergeRequestMessage struct {
-	MergeRequest              *gitlab.MergeRequest
-	Participants              []*gitlab.BasicUser
+	MergeRequest *gitlab.MergeRequest
+	// Participants are set in participants review mode only
+	Participants []*gitlab.BasicUser
+	// ApprovedBy and Approval are set in approvals review mode only
+	ApprovedBy                []*gitlab.BasicUser
+	Approval                  *gitlabservice.ApprovalStatus
 	MergeRequestReviewMention string
 	TimeSinceCreatedStr       string
 	TimeSinceUpdatedStr       string
@@ -76,8 +228,171 @@ func NewNeededReviewMergeRequestMessage(mrp *gitlabservice.MergeRequestWithParti
 	return NeededReviewMergeRequestMessage{
 		MergeRequest:              mrp.MergeRequest,
 		Participants:              mrp.Participants,
+		ApprovedBy:                mrp.ApprovedBy,
+		Approval:                  mrp.Approval,
 		MergeRequestReviewMention: config.MergeRequestReviewMention,
 		TimeSinceCreatedStr:       durafmt.Parse(timeSinceCreated).LimitFirstN(2).String(),
 		TimeSinceUpdatedStr:       durafmt.Parse(timeSinceUpdated).LimitFirstN(2).String(),
 	}
 }
+
+func (m NeededReviewMergeRequestMessage) makeCard(text string) webhook.Card {
+	card := makeMergeRequestCard(m.MergeRequest, text)
+	card.Facts = append(card.Facts,
+		webhook.Fact{Name: "Created", Value: m.TimeSinceCreatedStr + " ago"},
+		webhook.Fact{Name: "Last updated", Value: m.TimeSinceUpdatedStr + " ago"},
+	)
+	if m.Approval == nil {
+		card.Facts = append(card.Facts, webhook.Fact{Name: "Participants", Value: joinUsernames(dereferenceUsers(m.Participants))})
+		return card
+	}
+
+	card.Facts = append(card.Facts,
+		webhook.Fact{Name: "Approvals", Value: fmt.Sprintf("%d/%d", m.Approval.ApprovalsGiven, m.Approval.ApprovalsRequired)},
+		webhook.Fact{Name: "Approved by", Value: joinUsernames(dereferenceUsers(m.ApprovedBy))},
+	)
+	for _, rule := range m.Approval.MissingRules {
+		value := "any eligible member"
+		if len(rule.Approvers) > 0 {
+			value = joinUsernames(dereferenceUsers(rule.Approvers))
+		}
+		card.Facts = append(card.Facts, webhook.Fact{Name: fmt.Sprintf("%s (%d left)", rule.Name, rule.ApprovalsLeft), Value: value})
+	}
+	return card
+}
+
+func (m NeededReviewMergeRequestMessage) makeEvent(text string) webhook.Event {
+	participants := m.Participants
+	if m.Approval != nil {
+		participants = m.ApprovedBy
+	}
+	event := makeMergeRequestEvent(webhook.EventNeededReviewMergeRequest, m.MergeRequest, participants, text)
+	event.Mention = m.MergeRequestReviewMention
+	return event
+}
+
+func (m NeededReviewMergeRequestMessage) kind() string {
+	return config.KindNeededReviewMergeRequest
+}
+
+func (m NeededReviewMergeRequestMessage) projectId() int {
+	return m.MergeRequest.ProjectID
+}
+
+func (m NeededReviewMergeRequestMessage) mergeRequestIid() int {
+	return m.MergeRequest.IID
+}
+
+func (m NeededReviewMergeRequestMessage) discussionId() string {
+	return ""
+}
+
+func (m NeededReviewMergeRequestMessage) age() time.Duration {
+	// it is assumed that go-gitlab package returns timestamps in UTC
+	return time.Now().UTC().Sub(*m.MergeRequest.CreatedAt)
+}
+
+func (m NeededReviewMergeRequestMessage) color() string {
+	return ""
+}
+
+func (m NeededReviewMergeRequestMessage) users() []*gitlab.BasicUser {
+	users := append(mergeRequestUsers(m.MergeRequest), m.Participants...)
+	if m.Approval != nil {
+		users = append(users, m.ApprovedBy...)
+		users = append(users, m.Approval.MissingApprovers()...)
+	}
+	return users
+}
+
+// responsibles are assignees and reviewers or approvers who still have to approve in approvals review mode
+func (m NeededReviewMergeRequestMessage) responsibles() []*gitlab.BasicUser {
+	users := append([]*gitlab.BasicUser{}, m.MergeRequest.Assignees...)
+	if m.Approval != nil {
+		return append(users, m.Approval.MissingApprovers()...)
+	}
+	return append(users, m.Participants...)
+}
+
+func mergeRequestUsers(mr *gitlab.MergeRequest) []*gitlab.BasicUser {
+	users := []*gitlab.BasicUser{mr.Author}
+	if mr.Assignee != nil {
+		users = append(users, mr.Assignee)
+	}
+	return append(users, mr.Assignees...)
+}
+
+func makeMergeRequestCard(mr *gitlab.MergeRequest, text string) webhook.Card {
+	return webhook.Card{
+		Title:     fmt.Sprintf("Merge request %s: %s", mr.Reference, mr.Title),
+		TitleLink: mr.WebURL,
+		Text:      text,
+		Facts: []webhook.Fact{
+			{Name: "Merge request", Value: mr.Title},
+			{Name: "Reference", Value: mr.Reference},
+		},
+	}
+}
+
+func joinUsernames(users []gitlab.BasicUser) string {
+	if len(users) == 0 {
+		return "none"
+	}
+	usernames := make([]string, 0, len(users))
+	for _, user := range users {
+		usernames = append(usernames, "@"+user.Username)
+	}
+	return strings.Join(usernames, ", ")
+}
+
+func makeMergeRequestEvent(eventType string, mr *gitlab.MergeRequest, participants []*gitlab.BasicUser, text string) webhook.Event {
+	eventParticipants := make([]webhook.EventUser, 0, len(participants))
+	for _, participant := range participants {
+		eventParticipants = append(eventParticipants, *makeEventUser(participant.ID, participant.Username, participant.Name))
+	}
+
+	var author *webhook.EventUser
+	if mr.Author != nil {
+		author = makeEventUser(mr.Author.ID, mr.Author.Username, mr.Author.Name)
+	}
+
+	// it is assumed that go-gitlab package returns timestamps in UTC
+	now := time.Now().UTC()
+	return webhook.Event{
+		Version: webhook.EventVersion,
+		Type:    eventType,
+		SentAt:  now,
+		Text:    text,
+		MergeRequest: &webhook.EventMergeRequest{
+			Id:           mr.ID,
+			Iid:          mr.IID,
+			ProjectId:    mr.ProjectID,
+			Reference:    mr.Reference,
+			Title:        mr.Title,
+			WebUrl:       mr.WebURL,
+			Author:       author,
+			Upvotes:      mr.Upvotes,
+			SourceBranch: mr.SourceBranch,
+			TargetBranch: mr.TargetBranch,
+			CreatedAt:    mr.CreatedAt,
+			UpdatedAt:    mr.UpdatedAt,
+		},
+		Participants: eventParticipants,
+		Timings: webhook.EventTimings{
+			SinceCreated: int64(now.Sub(*mr.CreatedAt).Seconds()),
+			SinceUpdated: int64(now.Sub(*mr.UpdatedAt).Seconds()),
+		},
+	}
+}
+
+func makeEventUser(id int, username string, name string) *webhook.EventUser {
+	return &webhook.EventUser{Id: id, Username: username, Name: name}
+}
+
+func dereferenceUsers(users []*gitlab.BasicUser) []gitlab.BasicUser {
+	res := make([]gitlab.BasicUser, 0, len(users))
+	for _, user := range users {
+		res = append(res, *user)
+	}
+	return res
+}
@@ -2,8 +2,12 @@ package notifier
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	htmltemplate "html/template"
 	"path"
+	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/Masterminds/sprig"
@@ -11,64 +15,265 @@ import (
 
 	"gitlab-code-review-notifier/pkg/config"
 	"gitlab-code-review-notifier/pkg/gitlabservice"
+	"gitlab-code-review-notifier/pkg/interaction"
 	"gitlab-code-review-notifier/pkg/log"
 	"gitlab-code-review-notifier/pkg/webhook"
 )
 
+// EmailResolver resolves emails of gitlab users to send mails to
+type EmailResolver interface {
+	GetPublicEmail(userId int) string
+}
+
+type Options struct {
+	// Routes are additional destinations of notifications
+	Routes []Route
+	Emails EmailResolver
+	// DirectMessages enables sending messages directly to responsible users if webhook supports it
+	DirectMessages bool
+	UserMappings   []*config.UserMapping
+	ClientId       int
+	// Posts enables updating the last posts about the same items instead of sending new ones if webhook supports it
+	Posts PostStore
+	// Threads enables replying to the first posts about the same merge requests if webhook supports it
+	Threads ThreadStore
+	// Outbox enables delivering messages sent to channels later with retries instead of sending them right away
+	Outbox Outbox
+	// Buttons enables acknowledge and snooze buttons on alerts if webhook supports them
+	Buttons *interaction.Buttons
+}
+
+// Outbox delivers messages to the webhook of the target with the id or of the client if it is 0 retrying failed attempts
+type Outbox interface {
+	Enqueue(clientId int, targetId int, delivery webhook.Delivery) error
+}
+
 type Notifier struct {
+	// webhook receives notifications not accepted by any of routes
 	webhook          webhook.Webhook
+	routes           []Route
 	templatesBaseDir string
+	emails           EmailResolver
+	directMessages   bool
+	userMappings     *userMappings
+	clientId         int
+	posts            PostStore
+	threads          ThreadStore
+	outbox           Outbox
+	buttons          *interaction.Buttons
+	// chatUsers caches chat user ids by gitlab user id for each direct webhook
+	chatUsers map[webhook.DirectWebhook]map[int]string
+	mu        sync.Mutex
 	log.Loggable
 }
 
-func NewNotifier(webhook webhook.Webhook, templatesBaseDir string) *Notifier {
+func NewNotifier(hook webhook.Webhook, templatesBaseDir string, options Options) *Notifier {
 	return &Notifier{
-		webhook:          webhook,
+		webhook:          hook,
+		routes:           options.Routes,
 		templatesBaseDir: templatesBaseDir,
+		emails:           options.Emails,
+		directMessages:   options.DirectMessages,
+		userMappings:     newUserMappings(options.UserMappings),
+		clientId:         options.ClientId,
+		posts:            options.Posts,
+		threads:          options.Threads,
+		outbox:           options.Outbox,
+		buttons:          options.Buttons,
+		chatUsers:        make(map[webhook.DirectWebhook]map[int]string),
 	}
 }
 
-func (n *Notifier) NotifyOldOpenedMergeRequest(mr *gitlab.MergeRequest, config *config.FiringConfig) {
+func (n *Notifier) NotifyOldOpenedMergeRequest(mr *gitlab.MergeRequest, config *config.FiringConfig) error {
 	templateFileName := "old_merge_request.gotpl"
-	if err := n.notifyMessage(NewOldMergeRequestMessage(mr, config), templateFileName); err != nil {
-		n.Log().Errorf("Failed to notify message: %v", err)
-	}
+	return n.notifyMessage(NewOldMergeRequestMessage(mr, config), templateFileName)
 }
 
-func (n *Notifier) NotifyNeededReviewMergeRequest(mr *gitlabservice.MergeRequestWithParticipants, config *config.FiringConfig) {
+func (n *Notifier) NotifyNeededReviewMergeRequest(mr *gitlabservice.MergeRequestWithParticipants, config *config.FiringConfig) error {
 	templateFileName := "needed_review_merge_request.gotpl"
-	if err := n.notifyMessage(NewNeededReviewMergeRequestMessage(mr, config), templateFileName); err != nil {
-		n.Log().Errorf("Failed to notify message: %v", err)
-	}
+	return n.notifyMessage(NewNeededReviewMergeRequestMessage(mr, config), templateFileName)
 }
 
-func (n *Notifier) NotifyFiringMergeRequestDiscussions(fmr gitlabservice.FiringMergeRequest) {
+func (n *Notifier) NotifyFiringDiscussion(mr gitlab.MergeRequest, discussion gitlab.Discussion) error {
 	templateFileName := "firing_discussion.gotpl"
+	return n.notifyMessage(NewDiscussionMessage(mr, discussion), templateFileName)
+}
 
-	for _, message := range MakeDiscussionMessages(fmr) {
-		if err := n.notifyMessage(message, templateFileName); err != nil {
-			n.Log().Errorf("Failed to notify message: %v", err)
+func (n *Notifier) notifyMessage(data message, templateFileName string) error {
+	text, err := n.renderTemplate(data, templateFileName)
+	if err != nil {
+		return err
+	}
+
+	routed := false
+	var errs []error
+	for _, route := range n.routes {
+		if !route.accepts(data.kind(), data.projectId()) {
+			continue
+		}
+		routed = true
+		if err := n.send(route.Webhook, route.TargetId, data, templateFileName, text); err != nil {
+			errs = append(errs, fmt.Errorf("notify message to target %s: %w", route.Name, err))
 		}
 	}
+
+	if routed {
+		return joinErrors(errs)
+	}
+
+	return n.send(n.webhook, 0, data, templateFileName, text)
 }
 
-func (n *Notifier) notifyMessage(data interface{}, templateFileName string) error {
+// joinErrors returns nil if there are no errors, the only one or an error listing all of them
+// so that the notification is not considered sent if any of its routes failed
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// send sends the message to the webhook of the target with the id or of the client if it is 0
+func (n *Notifier) send(hook webhook.Webhook, targetId int, data message, templateFileName string, text string) error {
+	if directHook, ok := hook.(webhook.DirectWebhook); ok && n.directMessages {
+		return n.sendDirect(directHook, data, text)
+	}
+
+	if editableHook, ok := hook.(webhook.EditableWebhook); ok && n.posts != nil && data.kind() != kindDigest {
+		return n.sendEditable(editableHook, targetId, data, text)
+	}
+
+	if threadHook, ok := hook.(webhook.ThreadWebhook); ok && n.threads != nil && data.kind() != kindDigest {
+		return n.sendThreaded(threadHook, targetId, data, text)
+	}
+
+	delivery, err := n.makeDelivery(hook, data, templateFileName, text)
+	if err != nil {
+		return err
+	}
+
+	if n.outbox != nil {
+		err := n.outbox.Enqueue(n.clientId, targetId, delivery)
+		if err == nil {
+			return nil
+		}
+		n.Log().Warnf("Failed to enqueue %s message to outbox, sending it right away: %v", data.kind(), err)
+	}
+
+	return webhook.Deliver(hook, delivery)
+}
+
+// makeDelivery renders the message in the form the webhook sends it
+func (n *Notifier) makeDelivery(hook webhook.Webhook, data message, templateFileName string, text string) (webhook.Delivery, error) {
+	delivery := webhook.Delivery{
+		Text:  text,
+		Color: data.color(),
+	}
+
+	switch hook.(type) {
+	case webhook.EventWebhook:
+		event := data.makeEvent(text)
+		delivery.Event = &event
+	case webhook.MailWebhook:
+		htmlTemplateFileName := strings.TrimSuffix(templateFileName, ".gotpl") + ".html.gotpl"
+		html, err := n.renderHTMLTemplate(data, htmlTemplateFileName)
+		if err != nil {
+			return delivery, err
+		}
+		delivery.Mail = &webhook.Mail{
+			Subject:    data.makeCard(text).Title,
+			Text:       text,
+			HTML:       html,
+			Recipients: n.resolveEmails(data.users()),
+		}
+	case webhook.InteractiveWebhook, webhook.CardWebhook:
+		card := data.makeCard(text)
+		delivery.Card = &card
+	}
+
+	if _, ok := hook.(webhook.InteractiveWebhook); ok && n.buttons != nil && data.mergeRequestIid() != 0 {
+		delivery.Buttons = n.buttons.Make(interaction.Item{
+			ClientId:        n.clientId,
+			ProjectId:       data.projectId(),
+			MergeRequestIid: data.mergeRequestIid(),
+			DiscussionId:    data.discussionId(),
+			Kind:            data.kind(),
+		})
+	}
+
+	return delivery, nil
+}
+
+func (n *Notifier) renderTemplate(data interface{}, templateFileName string) (string, error) {
 	tplFilePath := path.Join(n.templatesBaseDir, templateFileName)
 	tpl, err := template.New(templateFileName).
 		Funcs(sprig.TxtFuncMap()).
+		Funcs(template.FuncMap{"chatMention": n.userMappings.chatMention}).
+		ParseFiles(tplFilePath)
+	if err != nil {
+		return "", fmt.Errorf("parse template %s: %v", tplFilePath, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("compile message template: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+func (n *Notifier) renderHTMLTemplate(data interface{}, templateFileName string) (string, error) {
+	tplFilePath := path.Join(n.templatesBaseDir, templateFileName)
+	tpl, err := htmltemplate.New(templateFileName).
+		Funcs(sprig.HtmlFuncMap()).
+		Funcs(htmltemplate.FuncMap{"chatMention": n.userMappings.chatMention}).
 		ParseFiles(tplFilePath)
 	if err != nil {
-		return fmt.Errorf("parse template %s: %v", tplFilePath, err)
+		return "", fmt.Errorf("parse html template %s: %v", tplFilePath, err)
 	}
 
 	buf := new(bytes.Buffer)
 	if err := tpl.Execute(buf, data); err != nil {
-		return fmt.Errorf("compile message template: %v", err)
+		return "", fmt.Errorf("compile html message template: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// resolveEmails returns unique public emails of users skipping the ones without it
+func (n *Notifier) resolveEmails(users []*gitlab.BasicUser) []string {
+	if n.emails == nil {
+		return nil
 	}
 
-	if err := n.webhook.Send(buf.String()); err != nil {
-		return fmt.Errorf("send webhook message: %v", err)
+	emails := make([]string, 0, len(users))
+	for _, user := range uniqueUsers(users) {
+		if email := n.emails.GetPublicEmail(user.ID); len(email) > 0 {
+			emails = append(emails, email)
+		}
 	}
 
-	return nil
+	return emails
+}
+
+// uniqueUsers removes duplicated and nil users
+func uniqueUsers(users []*gitlab.BasicUser) []*gitlab.BasicUser {
+	seen := make(map[int]bool)
+	res := make([]*gitlab.BasicUser, 0, len(users))
+	for _, user := range users {
+		if user == nil || seen[user.ID] {
+			continue
+		}
+		seen[user.ID] = true
+		res = append(res, user)
+	}
+	return res
 }
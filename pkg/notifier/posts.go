@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+const resolvedPostTemplateFileName = "resolved_post.gotpl"
+
+// PostStore persists posts sent by editable webhooks to update them later
+type PostStore interface {
+	// Find returns the post with the same key as the given one or nil if nothing was posted about the item
+	Find(key config.NotificationPost) (*config.NotificationPost, error)
+	GetUnresolvedByClient(clientId int) ([]*config.NotificationPost, error)
+	Save(post *config.NotificationPost) error
+}
+
+// ResolvedPostMessage is a data passed to the template of a resolved post
+type ResolvedPostMessage struct {
+	// Text is the last text of the post struck through
+	Text   string
+	Reason string
+}
+
+// sendEditable updates the last post about the same item if it is not resolved yet or sends a new one
+func (n *Notifier) sendEditable(hook webhook.EditableWebhook, targetId int, data message, text string) error {
+	key := config.NotificationPost{
+		ClientId:        n.clientId,
+		TargetId:        targetId,
+		Kind:            data.kind(),
+		ProjectId:       data.projectId(),
+		MergeRequestIid: data.mergeRequestIid(),
+		DiscussionId:    data.discussionId(),
+	}
+
+	post, err := n.posts.Find(key)
+	if err != nil {
+		n.Log().Warnf("Failed to find last %s post about merge request %d in project %d: %v", key.Kind, key.MergeRequestIid, key.ProjectId, err)
+	}
+
+	now := time.Now().UTC()
+
+	if post != nil && !post.Resolved {
+		err := hook.Update(post.PostId, text, data.color())
+		if err == nil {
+			post.Text = text
+			post.UpdatedAt = now
+			n.savePost(post)
+			return nil
+		}
+		n.Log().Warnf("Failed to update post %s, sending a new one: %v", post.PostId, err)
+	}
+
+	postId, err := hook.Post(text, data.color())
+	if err != nil {
+		return fmt.Errorf("send webhook post: %v", err)
+	}
+
+	key.PostId = postId
+	key.Text = text
+	key.CreatedAt = now
+	key.UpdatedAt = now
+	n.savePost(&key)
+
+	return nil
+}
+
+// UnresolvedPosts returns posts about items which were not resolved yet
+func (n *Notifier) UnresolvedPosts() ([]*config.NotificationPost, error) {
+	if n.posts == nil {
+		return nil, nil
+	}
+	return n.posts.GetUnresolvedByClient(n.clientId)
+}
+
+// ResolvePost strikes through the text of the post adding the reason why no actions are required anymore
+func (n *Notifier) ResolvePost(post *config.NotificationPost, reason string) error {
+	if hook, ok := n.targetWebhook(post.TargetId).(webhook.EditableWebhook); ok {
+		text, err := n.renderTemplate(ResolvedPostMessage{Text: strikeThrough(post.Text), Reason: reason}, resolvedPostTemplateFileName)
+		if err != nil {
+			return err
+		}
+		// resolved posts lose the color of the escalation step as no actions are required anymore
+		if err := hook.Update(post.PostId, text, ""); err != nil {
+			return fmt.Errorf("update webhook post %s: %v", post.PostId, err)
+		}
+		post.Text = text
+	}
+
+	post.Resolved = true
+	post.UpdatedAt = time.Now().UTC()
+	n.savePost(post)
+
+	return nil
+}
+
+// targetWebhook returns the webhook of the target with the id or of the client if it is 0
+func (n *Notifier) targetWebhook(targetId int) webhook.Webhook {
+	if targetId == 0 {
+		return n.webhook
+	}
+	for _, route := range n.routes {
+		if route.TargetId == targetId {
+			return route.Webhook
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) savePost(post *config.NotificationPost) {
+	if err := n.posts.Save(post); err != nil {
+		n.Log().Warnf("Failed to save post %s about merge request %d in project %d: %v", post.PostId, post.MergeRequestIid, post.ProjectId, err)
+	}
+}
+
+// strikeThrough strikes through each line of the markdown text keeping list markers intact
+func strikeThrough(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		content := strings.TrimSpace(line)
+		if len(content) == 0 {
+			continue
+		}
+		prefix := ""
+		if strings.HasPrefix(content, "- ") {
+			prefix, content = "- ", strings.TrimPrefix(content, "- ")
+		}
+		lines[i] = prefix + "~~" + content + "~~"
+	}
+	return strings.Join(lines, "\n")
+}
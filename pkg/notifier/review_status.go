@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"time"
+
+	"github.com/hako/durafmt"
+	"github.com/xanzy/go-gitlab"
+)
+
+const reviewStatusTemplateFileName = "review_status.gotpl"
+
+// ReviewStatus is the review queue of a gitlab user replied to the slash command
+type ReviewStatus struct {
+	Username string
+	// PendingReviews are merge requests of others assigned to the user or having discussions waiting for the user reply
+	PendingReviews []ReviewStatusItem
+	// OwnMergeRequests are merge requests of the user waiting for others
+	OwnMergeRequests   []ReviewStatusItem
+	StaleMergeRequests []ReviewStatusItem
+	// Incomplete tells that some merge requests failed to be fetched from gitlab
+	Incomplete bool
+}
+
+type ReviewStatusItem struct {
+	MergeRequest *gitlab.MergeRequest
+	// WaitingDiscussions is the number of discussions the author replied to longer than the firing timeout ago
+	WaitingDiscussions  int
+	TimeSinceUpdatedStr string
+}
+
+func NewReviewStatusItem(mr *gitlab.MergeRequest, waitingDiscussions int) ReviewStatusItem {
+	// it is assumed that go-gitlab package returns timestamps in UTC
+	timeSinceUpdated := time.Now().UTC().Sub(*mr.UpdatedAt)
+	return ReviewStatusItem{
+		MergeRequest:        mr,
+		WaitingDiscussions:  waitingDiscussions,
+		TimeSinceUpdatedStr: durafmt.Parse(timeSinceUpdated).LimitFirstN(2).String(),
+	}
+}
+
+func (n *Notifier) RenderReviewStatus(status ReviewStatus) (string, error) {
+	return n.renderTemplate(status, reviewStatusTemplateFileName)
+}
@@ -0,0 +1,38 @@
+package notifier
+
+import "gitlab-code-review-notifier/pkg/webhook"
+
+// Route is an additional destination receiving only notifications of the kinds about the projects
+type Route struct {
+	// TargetId is the id of the client target the route is made of
+	TargetId int
+	Name     string
+	Webhook  webhook.Webhook
+	// Kinds are notification kinds of the route, all kinds are accepted if empty
+	Kinds []string
+	// ProjectIds are ids of projects of the route, all projects are accepted if empty
+	ProjectIds []int
+}
+
+func (r Route) accepts(kind string, projectId int) bool {
+	return (len(r.Kinds) == 0 || containsString(r.Kinds, kind)) &&
+		(len(r.ProjectIds) == 0 || containsInt(r.ProjectIds, projectId))
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(items []int, item int) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
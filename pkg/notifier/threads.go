@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+// ThreadStore persists first posts about merge requests to reply to them later
+type ThreadStore interface {
+	// Find returns the thread with the same key as the given one or nil if nothing was posted about the merge request
+	Find(key config.NotificationThread) (*config.NotificationThread, error)
+	Save(thread *config.NotificationThread) error
+}
+
+// sendThreaded replies in the thread of the first post about the merge request or starts a new thread
+func (n *Notifier) sendThreaded(hook webhook.ThreadWebhook, targetId int, data message, text string) error {
+	key := config.NotificationThread{
+		ClientId:        n.clientId,
+		TargetId:        targetId,
+		ProjectId:       data.projectId(),
+		MergeRequestIid: data.mergeRequestIid(),
+	}
+
+	thread, err := n.threads.Find(key)
+	if err != nil {
+		n.Log().Warnf("Failed to find thread of merge request %d in project %d: %v", key.MergeRequestIid, key.ProjectId, err)
+	}
+
+	now := time.Now().UTC()
+
+	if thread != nil {
+		_, err := hook.Reply(thread.RootPostId, text, data.color())
+		if err == nil {
+			thread.UpdatedAt = now
+			n.saveThread(thread)
+			return nil
+		}
+		n.Log().Warnf("Failed to reply in thread %s, starting a new one: %v", thread.RootPostId, err)
+	}
+
+	postId, err := hook.Post(text, data.color())
+	if err != nil {
+		return fmt.Errorf("send webhook post: %v", err)
+	}
+
+	key.RootPostId = postId
+	key.CreatedAt = now
+	key.UpdatedAt = now
+	n.saveThread(&key)
+
+	return nil
+}
+
+func (n *Notifier) saveThread(thread *config.NotificationThread) {
+	if err := n.threads.Save(thread); err != nil {
+		n.Log().Warnf("Failed to save thread %s of merge request %d in project %d: %v", thread.RootPostId, thread.MergeRequestIid, thread.ProjectId, err)
+	}
+}
@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"github.com/xanzy/go-gitlab"
+
+	"gitlab-code-review-notifier/pkg/config"
+)
+
+// userMappings looks chat users up by gitlab user id or, if it is not set in the mapping, by gitlab username
+type userMappings struct {
+	byId       map[int]*config.UserMapping
+	byUsername map[string]*config.UserMapping
+}
+
+func newUserMappings(mappings []*config.UserMapping) *userMappings {
+	m := &userMappings{
+		byId:       make(map[int]*config.UserMapping),
+		byUsername: make(map[string]*config.UserMapping),
+	}
+	for _, mapping := range mappings {
+		if mapping.GitlabUserId != 0 {
+			m.byId[mapping.GitlabUserId] = mapping
+		}
+		m.byUsername[mapping.GitlabUsername] = mapping
+	}
+	return m
+}
+
+func (m *userMappings) find(user *gitlab.BasicUser) *config.UserMapping {
+	if mapping, ok := m.byId[user.ID]; ok {
+		return mapping
+	}
+	return m.byUsername[user.Username]
+}
+
+// chatMention is a template function making a chat mention of a gitlab user given as
+// gitlab.BasicUser or username, the gitlab username is used if there is no mapping
+func (m *userMappings) chatMention(user interface{}) string {
+	var basicUser *gitlab.BasicUser
+	switch u := user.(type) {
+	case gitlab.BasicUser:
+		basicUser = &u
+	case *gitlab.BasicUser:
+		basicUser = u
+	case string:
+		basicUser = &gitlab.BasicUser{Username: u}
+	default:
+		return ""
+	}
+
+	if basicUser == nil {
+		return ""
+	}
+	if mapping := m.find(basicUser); mapping != nil {
+		return "@" + mapping.ChatUsername
+	}
+	return "@" + basicUser.Username
+}
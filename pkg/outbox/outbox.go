@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+// Store persists outbox entries
+type Store interface {
+	Create(entry *config.OutboxEntry) error
+	// ClaimDue returns pending entries due at the time postponing them by the lease so that concurrent workers skip them
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*config.OutboxEntry, error)
+	Update(entry *config.OutboxEntry) error
+}
+
+// Outbox stores deliveries to be sent by the Worker
+type Outbox struct {
+	store Store
+}
+
+func NewOutbox(store Store) *Outbox {
+	return &Outbox{store: store}
+}
+
+func (o *Outbox) Enqueue(clientId int, targetId int, delivery webhook.Delivery) error {
+	payload, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("serialize delivery to json: %v", err)
+	}
+
+	entry := &config.OutboxEntry{
+		ClientId:      clientId,
+		TargetId:      targetId,
+		Payload:       string(payload),
+		Status:        config.OutboxStatusPending,
+		NextAttemptAt: time.Now().UTC(),
+	}
+	if err := o.store.Create(entry); err != nil {
+		return fmt.Errorf("create outbox entry: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/config"
+	"gitlab-code-review-notifier/pkg/log"
+	"gitlab-code-review-notifier/pkg/webhook"
+)
+
+const (
+	// claimLease is how long claimed entries are hidden from other workers while being delivered
+	claimLease = 5 * time.Minute
+	batchSize  = 100
+)
+
+// WebhookResolver makes the webhook of the target with the id or of the client if it is 0
+type WebhookResolver interface {
+	Resolve(clientId int, targetId int) (webhook.Webhook, error)
+}
+
+type WorkerConfig struct {
+	// Interval is how often the outbox is checked for due entries
+	Interval time.Duration
+	// MaxAttempts is the number of attempts after which the entry is dead-lettered
+	MaxAttempts int
+	// BaseDelay is the delay before the second attempt, each next one is doubled up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Worker delivers outbox entries retrying failed attempts with exponential backoff
+type Worker struct {
+	store    Store
+	resolver WebhookResolver
+	config   WorkerConfig
+	log.Loggable
+}
+
+func NewWorker(store Store, resolver WebhookResolver, config WorkerConfig) *Worker {
+	return &Worker{
+		store:    store,
+		resolver: resolver,
+		config:   config,
+	}
+}
+
+func (w *Worker) Run() {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		w.DeliverDue()
+	}
+}
+
+// DeliverDue delivers all entries due at the moment
+func (w *Worker) DeliverDue() {
+	for {
+		entries, err := w.store.ClaimDue(time.Now().UTC(), claimLease, batchSize)
+		if err != nil {
+			w.Log().Errorf("Failed to claim due outbox entries: %v", err)
+			return
+		}
+		for _, entry := range entries {
+			w.deliver(entry)
+		}
+		if len(entries) < batchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) deliver(entry *config.OutboxEntry) {
+	err := w.send(entry)
+	entry.Attempts++
+
+	switch {
+	case err == nil:
+		entry.Status = config.OutboxStatusDelivered
+		entry.LastError = ""
+	case entry.Attempts >= w.config.MaxAttempts || !webhook.IsRetryable(err):
+		w.Log().Errorf("Failed to deliver outbox entry %d of client %d, giving up after %d attempts: %v", entry.Id, entry.ClientId, entry.Attempts, err)
+		entry.Status = config.OutboxStatusFailed
+		entry.LastError = err.Error()
+	default:
+		delay := w.retryDelay(entry.Attempts, err)
+		w.Log().Warnf("Failed to deliver outbox entry %d of client %d, attempt %d, retrying in %v: %v", entry.Id, entry.ClientId, entry.Attempts, delay, err)
+		entry.LastError = err.Error()
+		entry.NextAttemptAt = time.Now().UTC().Add(delay)
+	}
+
+	if err := w.store.Update(entry); err != nil {
+		w.Log().Errorf("Failed to update outbox entry %d: %v", entry.Id, err)
+	}
+}
+
+func (w *Worker) send(entry *config.OutboxEntry) error {
+	var delivery webhook.Delivery
+	if err := json.Unmarshal([]byte(entry.Payload), &delivery); err != nil {
+		return fmt.Errorf("deserialize delivery from json: %v", err)
+	}
+
+	hook, err := w.resolver.Resolve(entry.ClientId, entry.TargetId)
+	if err != nil {
+		return fmt.Errorf("resolve webhook of target %d: %v", entry.TargetId, err)
+	}
+
+	return webhook.Deliver(hook, delivery)
+}
+
+// retryDelay returns the delay asked by the server or the exponential one with jitter
+// spreading retries of entries failed at the same time
+func (w *Worker) retryDelay(attempt int, err error) time.Duration {
+	var statusErr *webhook.StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter
+	}
+
+	delay := w.config.BaseDelay
+	for i := 1; i < attempt && delay < w.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > w.config.MaxDelay {
+		delay = w.config.MaxDelay
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
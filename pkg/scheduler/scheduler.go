@@ -48,6 +48,11 @@ func (s *Scheduler) SubmitFixed(job func(), timeAt string) error {
 		Do(s.workdayJobWrapper(job))
 }
 
+// RunNow runs the job right away unless it is out of work hours, as scheduled jobs would
+func (s *Scheduler) RunNow(job func()) {
+	s.workdayJobWrapper(job)()
+}
+
 func (s *Scheduler) workdayJobWrapper(job func()) func() {
 	return func() {
 		now := time.Now().In(s.config.TimeZone)
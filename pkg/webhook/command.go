@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const commandResponseEphemeral = "ephemeral"
+
+// CommandResponse is a reply to a mattermost or slack slash command
+type CommandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// NewEphemeralCommandResponse makes a reply visible only to the caller with the text formatted for the chat of the webhook type
+func NewEphemeralCommandResponse(webhookType string, text string) CommandResponse {
+	if webhookType == TypeSlack || webhookType == TypeSlackBot {
+		text = slackMarkdown(text)
+	}
+	return CommandResponse{
+		ResponseType: commandResponseEphemeral,
+		Text:         text,
+	}
+}
+
+// RespondCommand sends the delayed reply to the response url of the slash command
+func RespondCommand(responseUrl string, response CommandResponse) error {
+	if err := doJSON(http.MethodPost, responseUrl, response, nil, nil); err != nil {
+		return fmt.Errorf("respond to slash command: %w", err)
+	}
+	return nil
+}
+
+// VerifyCommandResponseUrl checks that the response url of a slash command is on the chat of the webhook
+// so that replies aren't sent anywhere else, slack sends response urls on hooks.slack.com
+// and mattermost on the server the webhook url is on
+func VerifyCommandResponseUrl(webhookType string, webhookUrl string, responseUrl string) error {
+	respUrl, err := url.Parse(responseUrl)
+	if err != nil {
+		return fmt.Errorf("parse response url: %v", err)
+	}
+
+	switch webhookType {
+	case TypeSlack, TypeSlackBot:
+		if respUrl.Scheme != "https" || respUrl.Host != slackResponseHost {
+			return fmt.Errorf("response url of slack command is not on %s", slackResponseHost)
+		}
+	case "", TypeMattermost, TypeMattermostBot:
+		serverUrl, err := url.Parse(webhookUrl)
+		if err != nil || len(serverUrl.Host) == 0 {
+			return fmt.Errorf("mattermost server of the webhook is unknown")
+		}
+		if respUrl.Scheme != serverUrl.Scheme || respUrl.Host != serverUrl.Host {
+			return fmt.Errorf("response url of mattermost command is not on %s", serverUrl.Host)
+		}
+	default:
+		return fmt.Errorf("slash commands are not supported by %s webhooks", webhookType)
+	}
+	return nil
+}
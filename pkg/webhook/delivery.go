@@ -0,0 +1,59 @@
+package webhook
+
+import "fmt"
+
+// Delivery is a notification rendered in each form the webhook it is made for may need,
+// it is serializable to deliver it later
+type Delivery struct {
+	Text  string `json:"text"`
+	Color string `json:"color,omitempty"`
+	Card  *Card  `json:"card,omitempty"`
+	Event *Event `json:"event,omitempty"`
+	Mail  *Mail  `json:"mail,omitempty"`
+	// Buttons are attached to the card by interactive webhooks
+	Buttons []Button `json:"buttons,omitempty"`
+}
+
+// Deliver sends the delivery in the richest form supported by both the webhook and the delivery
+func Deliver(hook Webhook, delivery Delivery) error {
+	if eventHook, ok := hook.(EventWebhook); ok && delivery.Event != nil {
+		if err := eventHook.SendEvent(*delivery.Event); err != nil {
+			return fmt.Errorf("send webhook event: %w", err)
+		}
+		return nil
+	}
+
+	if mailHook, ok := hook.(MailWebhook); ok && delivery.Mail != nil {
+		if err := mailHook.SendMail(*delivery.Mail); err != nil {
+			return fmt.Errorf("send mail: %w", err)
+		}
+		return nil
+	}
+
+	if interactiveHook, ok := hook.(InteractiveWebhook); ok && delivery.Card != nil && len(delivery.Buttons) > 0 {
+		if err := interactiveHook.SendInteractive(*delivery.Card, delivery.Buttons); err != nil {
+			return fmt.Errorf("send webhook message with buttons: %w", err)
+		}
+		return nil
+	}
+
+	if cardHook, ok := hook.(CardWebhook); ok && delivery.Card != nil {
+		if err := cardHook.SendCard(*delivery.Card); err != nil {
+			return fmt.Errorf("send webhook card: %w", err)
+		}
+		return nil
+	}
+
+	if colorHook, ok := hook.(ColorWebhook); ok {
+		if err := colorHook.SendColored(delivery.Text, delivery.Color); err != nil {
+			return fmt.Errorf("send webhook message: %w", err)
+		}
+		return nil
+	}
+
+	if err := hook.Send(delivery.Text); err != nil {
+		return fmt.Errorf("send webhook message: %w", err)
+	}
+
+	return nil
+}
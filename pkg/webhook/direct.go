@@ -0,0 +1,14 @@
+package webhook
+
+import "errors"
+
+var ErrUserNotFound = errors.New("chat user not found")
+
+// DirectWebhook is implemented by webhooks that send messages with a bot and so can message users directly
+type DirectWebhook interface {
+	Webhook
+	// FindUser finds id of the chat user by username or email, returns ErrUserNotFound if there is no such user
+	FindUser(username string, email string) (string, error)
+	// SendDirect sends the message directly to the chat user with the id
+	SendDirect(userId string, text string) error
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"strconv"
+	"strings"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+// discordMaxEmbedDescriptionLen is a discord limit of embed description length
+const discordMaxEmbedDescriptionLen = 4096
+
+type DiscordConfig struct {
+	WebhookUrl   string
+	Username     string
+	AvatarUrl    string
+	DefaultColor string
+}
+
+type Discord struct {
+	config DiscordConfig
+	log.Loggable
+}
+
+func NewDiscord(config DiscordConfig) *Discord {
+	return &Discord{
+		config: config,
+	}
+}
+
+type DiscordMessage struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarUrl string         `json:"avatar_url,omitempty"`
+	Content   string         `json:"content,omitempty"`
+	Embeds    []DiscordEmbed `json:"embeds,omitempty"`
+}
+
+type DiscordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	Url         string              `json:"url,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color,omitempty"`
+	Fields      []DiscordEmbedField `json:"fields,omitempty"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func (d *Discord) Send(text string) error {
+	return d.SendCard(Card{Text: text})
+}
+
+func (d *Discord) SendCard(card Card) error {
+	color := card.Color
+	if len(color) == 0 {
+		color = d.config.DefaultColor
+	}
+
+	description := card.Text
+	if runes := []rune(description); len(runes) > discordMaxEmbedDescriptionLen {
+		description = string(runes[:discordMaxEmbedDescriptionLen])
+	}
+
+	fields := make([]DiscordEmbedField, 0, len(card.Facts))
+	for _, fact := range card.Facts {
+		fields = append(fields, DiscordEmbedField{Name: fact.Name, Value: fact.Value, Inline: true})
+	}
+
+	return d.SendMessage(DiscordMessage{
+		Username:  d.config.Username,
+		AvatarUrl: d.config.AvatarUrl,
+		Embeds: []DiscordEmbed{
+			{
+				Title:       card.Title,
+				Url:         card.TitleLink,
+				Description: description,
+				Color:       parseHexColor(color),
+				Fields:      fields,
+			},
+		},
+	})
+}
+
+func (d *Discord) SendMessage(message DiscordMessage) error {
+	return postJSON(d.config.WebhookUrl, message)
+}
+
+func (d *Discord) MaxTextLength() int {
+	return discordMaxEmbedDescriptionLen
+}
+
+// parseHexColor converts "#ff0000" color to integer or returns 0 (no color) if it is malformed
+func parseHexColor(color string) int {
+	val, err := strconv.ParseInt(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		return 0
+	}
+	return int(val)
+}
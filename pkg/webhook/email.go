@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+const (
+	// EmailSecurityStartTLS upgrades a plain connection with STARTTLS command
+	EmailSecurityStartTLS = "starttls"
+	// EmailSecurityTLS connects with implicit TLS usually on 465 port
+	EmailSecurityTLS = "tls"
+	// EmailSecurityNone sends mails over a plain connection
+	EmailSecurityNone = "none"
+)
+
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Security string
+	// Recipients are fixed recipients of all mails, if empty recipients of each mail are used
+	Recipients []string
+}
+
+type Email struct {
+	config EmailConfig
+	log.Loggable
+}
+
+func NewEmail(config EmailConfig) *Email {
+	return &Email{
+		config: config,
+	}
+}
+
+// Mail is a multipart mail with plain text and optional HTML bodies
+type Mail struct {
+	Subject    string
+	Text       string
+	HTML       string
+	Recipients []string
+}
+
+// MailWebhook is implemented by webhooks that send mails and need an HTML body along with the text one
+type MailWebhook interface {
+	Webhook
+	SendMail(mail Mail) error
+}
+
+func (e *Email) Send(text string) error {
+	return e.SendMail(Mail{
+		Subject: strings.SplitN(text, "\n", 2)[0],
+		Text:    text,
+	})
+}
+
+func (e *Email) SendMail(mail Mail) error {
+	recipients := e.config.Recipients
+	if len(recipients) == 0 {
+		recipients = mail.Recipients
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients for mail '%s'", mail.Subject)
+	}
+
+	data, err := e.makeMessage(mail, recipients)
+	if err != nil {
+		return fmt.Errorf("make mail message: %v", err)
+	}
+
+	client, err := e.dial()
+	if err != nil {
+		return fmt.Errorf("connect to smtp server %s:%d: %w", e.config.Host, e.config.Port, err)
+	}
+	defer client.Close()
+
+	if len(e.config.Username) > 0 {
+		if err := client.Auth(smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)); err != nil {
+			return fmt.Errorf("authenticate as %s: %w", e.config.Username, err)
+		}
+	}
+
+	if err := client.Mail(e.config.From); err != nil {
+		return fmt.Errorf("set sender %s: %w", e.config.From, err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("add recipient %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("start mail data: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("write mail data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("finish mail data: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (e *Email) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	tlsConfig := &tls.Config{ServerName: e.config.Host}
+
+	if e.config.Security == EmailSecurityTLS {
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return smtp.NewClient(conn, e.config.Host)
+	}
+
+	client, err := smtp.Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.config.Security != EmailSecurityNone {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("starttls: %v", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (e *Email) makeMessage(mail Mail, recipients []string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	body := multipart.NewWriter(buf)
+
+	headers := [][2]string{
+		{"From", e.config.From},
+		{"To", strings.Join(recipients, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", mail.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf(`multipart/alternative; boundary="%s"`, body.Boundary())},
+	}
+	for _, header := range headers {
+		buf.WriteString(fmt.Sprintf("%s: %s\r\n", header[0], header[1]))
+	}
+	buf.WriteString("\r\n")
+
+	// the preferred alternative goes last
+	if err := writeMailPart(body, "text/plain", mail.Text); err != nil {
+		return nil, err
+	}
+	if len(mail.HTML) > 0 {
+		if err := writeMailPart(body, "text/html", mail.HTML); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeMailPart(body *multipart.Writer, contentType string, content string) error {
+	part, err := body.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return fmt.Errorf("create %s part: %v", contentType, err)
+	}
+
+	writer := quotedprintable.NewWriter(part)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return fmt.Errorf("write %s part: %v", contentType, err)
+	}
+	return writer.Close()
+}
@@ -0,0 +1,71 @@
+package webhook
+
+import "time"
+
+// EventVersion is a version of the Event document, it is increased on incompatible changes
+const EventVersion = 1
+
+const (
+	EventMessage                  = "message"
+	EventOldMergeRequest          = "old_merge_request"
+	EventNeededReviewMergeRequest = "needed_review_merge_request"
+	EventFiringDiscussion         = "firing_discussion"
+	// EventDigest groups events of all notifications of a single run in Items
+	EventDigest = "digest"
+)
+
+// Event is a structured notification for webhooks that send data instead of a rendered text
+type Event struct {
+	Version      int                `json:"version"`
+	Type         string             `json:"type"`
+	SentAt       time.Time          `json:"sent_at"`
+	Text         string             `json:"text"`
+	Mention      string             `json:"mention,omitempty"`
+	MergeRequest *EventMergeRequest `json:"merge_request,omitempty"`
+	Discussion   *EventDiscussion   `json:"discussion,omitempty"`
+	Participants []EventUser        `json:"participants"`
+	Timings      EventTimings       `json:"timings"`
+	Items        []Event            `json:"items,omitempty"`
+}
+
+type EventMergeRequest struct {
+	Id           int        `json:"id"`
+	Iid          int        `json:"iid"`
+	ProjectId    int        `json:"project_id"`
+	Reference    string     `json:"reference"`
+	Title        string     `json:"title"`
+	WebUrl       string     `json:"web_url"`
+	Author       *EventUser `json:"author"`
+	Upvotes      int        `json:"upvotes"`
+	SourceBranch string     `json:"source_branch"`
+	TargetBranch string     `json:"target_branch"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type EventDiscussion struct {
+	Id                string     `json:"id"`
+	WebUrl            string     `json:"web_url"`
+	LastNoteId        int        `json:"last_note_id"`
+	LastNoteAuthor    *EventUser `json:"last_note_author"`
+	LastNoteCreatedAt *time.Time `json:"last_note_created_at"`
+}
+
+type EventUser struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// EventTimings are durations in seconds passed since the moments the notification is based on
+type EventTimings struct {
+	SinceCreated  int64 `json:"since_created"`
+	SinceUpdated  int64 `json:"since_updated"`
+	SinceLastNote int64 `json:"since_last_note,omitempty"`
+}
+
+// EventWebhook is implemented by webhooks that send structured events
+type EventWebhook interface {
+	Webhook
+	SendEvent(event Event) error
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+const (
+	GenericEventHeader     = "X-Notifier-Event"
+	GenericSignatureHeader = "X-Notifier-Signature"
+)
+
+type GenericConfig struct {
+	WebhookUrl string
+	// Secret is a key of HMAC-SHA256 signature of the request body, requests are not signed if empty
+	Secret string
+}
+
+// Generic posts events as JSON documents to an arbitrary url
+type Generic struct {
+	config GenericConfig
+	log.Loggable
+}
+
+func NewGeneric(config GenericConfig) *Generic {
+	return &Generic{
+		config: config,
+	}
+}
+
+func (g *Generic) Send(text string) error {
+	return g.SendEvent(Event{
+		Version:      EventVersion,
+		Type:         EventMessage,
+		SentAt:       time.Now().UTC(),
+		Text:         text,
+		Participants: []EventUser{},
+	})
+}
+
+func (g *Generic) SendEvent(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("serialize event %s to json: %v", event.Type, err)
+	}
+
+	headers := map[string]string{
+		GenericEventHeader: event.Type,
+	}
+	if len(g.config.Secret) > 0 {
+		headers[GenericSignatureHeader] = "sha256=" + Sign(data, g.config.Secret)
+	}
+
+	return post(g.config.WebhookUrl, data, headers)
+}
+
+// Sign returns hex encoded HMAC-SHA256 of data so receivers can verify the signature header
+func Sign(data []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"strings"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+type GoogleChatConfig struct {
+	WebhookUrl string
+}
+
+// GoogleChat sends messages as cards which have no colors so cards color is ignored
+type GoogleChat struct {
+	config GoogleChatConfig
+	log.Loggable
+}
+
+func NewGoogleChat(config GoogleChatConfig) *GoogleChat {
+	return &GoogleChat{
+		config: config,
+	}
+}
+
+type GoogleChatMessage struct {
+	Text  string           `json:"text,omitempty"`
+	Cards []GoogleChatCard `json:"cards,omitempty"`
+}
+
+type GoogleChatCard struct {
+	Header   *GoogleChatCardHeader `json:"header,omitempty"`
+	Sections []GoogleChatSection   `json:"sections"`
+}
+
+type GoogleChatCardHeader struct {
+	Title string `json:"title"`
+}
+
+type GoogleChatSection struct {
+	Widgets []GoogleChatWidget `json:"widgets"`
+}
+
+type GoogleChatWidget struct {
+	TextParagraph *GoogleChatTextParagraph `json:"textParagraph,omitempty"`
+	KeyValue      *GoogleChatKeyValue      `json:"keyValue,omitempty"`
+	Buttons       []GoogleChatButton       `json:"buttons,omitempty"`
+}
+
+type GoogleChatTextParagraph struct {
+	Text string `json:"text"`
+}
+
+type GoogleChatKeyValue struct {
+	TopLabel string `json:"topLabel"`
+	Content  string `json:"content"`
+}
+
+type GoogleChatButton struct {
+	TextButton GoogleChatTextButton `json:"textButton"`
+}
+
+type GoogleChatTextButton struct {
+	Text    string            `json:"text"`
+	OnClick GoogleChatOnClick `json:"onClick"`
+}
+
+type GoogleChatOnClick struct {
+	OpenLink GoogleChatOpenLink `json:"openLink"`
+}
+
+type GoogleChatOpenLink struct {
+	Url string `json:"url"`
+}
+
+func (g *GoogleChat) Send(text string) error {
+	return g.SendCard(Card{Text: text})
+}
+
+func (g *GoogleChat) SendCard(card Card) error {
+	// text paragraphs support only basic HTML tags and line breaks have to be explicit
+	text := strings.ReplaceAll(markdownToHTML(emojiShortcodes.Replace(card.Text)), "\n", "<br>")
+
+	widgets := []GoogleChatWidget{
+		{TextParagraph: &GoogleChatTextParagraph{Text: text}},
+	}
+	for _, fact := range card.Facts {
+		widgets = append(widgets, GoogleChatWidget{
+			KeyValue: &GoogleChatKeyValue{TopLabel: fact.Name, Content: fact.Value},
+		})
+	}
+	if len(card.TitleLink) > 0 {
+		widgets = append(widgets, GoogleChatWidget{
+			Buttons: []GoogleChatButton{
+				{
+					TextButton: GoogleChatTextButton{
+						Text:    "OPEN IN GITLAB",
+						OnClick: GoogleChatOnClick{OpenLink: GoogleChatOpenLink{Url: card.TitleLink}},
+					},
+				},
+			},
+		})
+	}
+
+	googleChatCard := GoogleChatCard{
+		Sections: []GoogleChatSection{{Widgets: widgets}},
+	}
+	if len(card.Title) > 0 {
+		googleChatCard.Header = &GoogleChatCardHeader{Title: card.Title}
+	}
+
+	return g.SendMessage(GoogleChatMessage{
+		Cards: []GoogleChatCard{googleChatCard},
+	})
+}
+
+func (g *GoogleChat) SendMessage(message GoogleChatMessage) error {
+	return postJSON(g.config.WebhookUrl, message)
+}
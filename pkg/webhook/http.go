@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxErrorBodyLen limits how much of an unexpected response body gets into an error message
+const maxErrorBodyLen = 500
+
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is how long the server asked to wait before the next request, 0 if it didn't
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code is %d body '%s'", e.StatusCode, e.Body)
+}
+
+func newHttpClient() *http.Client {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	}
+	return &http.Client{Transport: transport}
+}
+
+func postJSON(url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("serialize message %v to json: %v", payload, err)
+	}
+	return post(url, data, nil)
+}
+
+func post(url string, data []byte, headers map[string]string) error {
+	return doRequest(http.MethodPost, url, data, headers, nil)
+}
+
+// doJSON does the request with the payload serialized to JSON unless it is nil
+// and deserializes JSON response into the result unless it is nil
+func doJSON(method string, url string, payload interface{}, headers map[string]string, result interface{}) error {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("serialize payload %v to json: %v", payload, err)
+		}
+	}
+	return doRequest(method, url, data, headers, result)
+}
+
+func doRequest(method string, requestUrl string, data []byte, headers map[string]string, result interface{}) error {
+	req, err := http.NewRequest(method, requestUrl, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("create request: %v", redactUrlError(err))
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := newHttpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", redactUrlError(err))
+	}
+
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("deserialize response: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// redactUrlError leaves only the scheme and the host of the url in the error
+// as paths of webhook urls and telegram api urls contain secrets which must not get into logs
+func redactUrlError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	redacted := "<redacted>"
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil && len(u.Host) > 0 {
+		redacted = u.Scheme + "://" + u.Host + "/<redacted>"
+	}
+	return &url.Error{Op: urlErr.Op, URL: redacted, Err: urlErr.Err}
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if len(body) > maxErrorBodyLen {
+		body = body[:maxErrorBodyLen]
+	}
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses Retry-After header value given either in seconds or as a date
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(time.Now()) {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// IsRetryable reports whether a request failed due to network or server side problems which may go away,
+// other failures like rejected requests, chat errors or misconfigured webhooks are permanent
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var replyErr *textproto.Error
+	if errors.As(err, &replyErr) {
+		// smtp servers reply with 4xx codes on transient failures and with 5xx codes on permanent ones
+		return replyErr.Code >= 400 && replyErr.Code < 500
+	}
+	// connection failures and timeouts
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
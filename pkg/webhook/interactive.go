@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// slackStatusBlockId identifies the block showing who acted on the message
+const slackStatusBlockId = "status"
+
+const (
+	// slackSignatureMaxAge limits how old signed slack requests are accepted to prevent their replay
+	slackSignatureMaxAge = 5 * time.Minute
+	// slackResponseHost is the only host slack sends response urls of interactions on
+	slackResponseHost = "hooks.slack.com"
+)
+
+var ErrInvalidSlackSignature = errors.New("invalid slack signature")
+
+// Button is an interactive message button, a click on it is sent back to the notifier along with the value
+type Button struct {
+	// Action identifies the button, it consists of lowercase letters only as mattermost requires
+	Action string `json:"action"`
+	Label  string `json:"label"`
+	Value  string `json:"value"`
+	// CallbackUrl is used by webhooks whose clicks are sent to a url given in the message
+	// instead of the one configured in the chat app
+	CallbackUrl string `json:"callback_url,omitempty"`
+}
+
+// InteractiveWebhook is implemented by webhooks that can attach buttons to a message
+type InteractiveWebhook interface {
+	Webhook
+	SendInteractive(card Card, buttons []Button) error
+}
+
+type MattermostAction struct {
+	Id          string                `json:"id"`
+	Name        string                `json:"name"`
+	Integration MattermostIntegration `json:"integration"`
+}
+
+type MattermostIntegration struct {
+	Url     string                  `json:"url"`
+	Context MattermostActionContext `json:"context"`
+}
+
+// MattermostActionContext is sent back by mattermost on a button click,
+// it carries the original text of the message as mattermost doesn't send the post itself
+type MattermostActionContext struct {
+	Action string `json:"action"`
+	Value  string `json:"value"`
+	Text   string `json:"text"`
+	Color  string `json:"color"`
+}
+
+// MattermostActionRequest is sent by mattermost when a user clicks a button of a message
+type MattermostActionRequest struct {
+	UserId   string                  `json:"user_id"`
+	UserName string                  `json:"user_name"`
+	PostId   string                  `json:"post_id"`
+	Context  MattermostActionContext `json:"context"`
+}
+
+type MattermostActionResponse struct {
+	Update        *MattermostPost `json:"update,omitempty"`
+	EphemeralText string          `json:"ephemeral_text,omitempty"`
+}
+
+// NewMattermostActionResponse updates the clicked message adding the status of the item below its original text
+func NewMattermostActionResponse(request MattermostActionRequest, status string, buttons []Button) MattermostActionResponse {
+	attachment := makeMattermostInteractiveAttachment(request.Context.Text, request.Context.Color, status, buttons)
+	return MattermostActionResponse{
+		Update: &MattermostPost{
+			Props: map[string]interface{}{
+				"attachments": []MattermostAttachment{attachment},
+			},
+		},
+		EphemeralText: status,
+	}
+}
+
+// makeMattermostInteractiveAttachment keeps the text without the status in contexts of buttons
+// so that the status is replaced rather than appended on next clicks
+func makeMattermostInteractiveAttachment(text string, color string, status string, buttons []Button) MattermostAttachment {
+	attachment := MattermostAttachment{
+		Color: color,
+		Text:  text,
+	}
+	if len(status) > 0 {
+		attachment.Text += "\n\n" + status
+	}
+	for _, button := range buttons {
+		attachment.Actions = append(attachment.Actions, MattermostAction{
+			Id:   button.Action,
+			Name: button.Label,
+			Integration: MattermostIntegration{
+				Url: button.CallbackUrl,
+				Context: MattermostActionContext{
+					Action: button.Action,
+					Value:  button.Value,
+					Text:   text,
+					Color:  color,
+				},
+			},
+		})
+	}
+	return attachment
+}
+
+// SlackInteraction is sent by slack to the request url of the app when a user clicks a button of a message
+type SlackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		Id       string `json:"id"`
+		Username string `json:"username"`
+		Name     string `json:"name"`
+	} `json:"user"`
+	Actions []struct {
+		ActionId string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseUrl string       `json:"response_url"`
+	Message     SlackMessage `json:"message"`
+}
+
+type slackActionResponse struct {
+	ReplaceOriginal bool `json:"replace_original"`
+	SlackMessage
+}
+
+// RespondSlackInteraction replaces the clicked message with the same one showing the status of the item
+func RespondSlackInteraction(interaction SlackInteraction, status string) error {
+	message := interaction.Message
+	message.Ts, message.ThreadTs = "", ""
+	if len(message.Attachments) > 0 {
+		attachment := &message.Attachments[0]
+		blocks := make([]SlackBlock, 0, len(attachment.Blocks)+1)
+		for _, block := range attachment.Blocks {
+			if block.BlockId != slackStatusBlockId {
+				blocks = append(blocks, block)
+			}
+		}
+		attachment.Blocks = append(blocks, SlackBlock{
+			Type:     "context",
+			BlockId:  slackStatusBlockId,
+			Elements: []interface{}{SlackText{Type: "mrkdwn", Text: status}},
+		})
+	}
+
+	resp := slackActionResponse{ReplaceOriginal: true, SlackMessage: message}
+	if err := doJSON(http.MethodPost, interaction.ResponseUrl, resp, nil, nil); err != nil {
+		return fmt.Errorf("respond to slack interaction: %w", err)
+	}
+	return nil
+}
+
+// ParseSlackInteraction verifies the request is signed by slack with the signing secret of the app
+// and parses the payload of the form slack posts on a button click
+func ParseSlackInteraction(r *http.Request, signingSecret string) (SlackInteraction, error) {
+	var interaction SlackInteraction
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return interaction, fmt.Errorf("read slack interaction: %v", err)
+	}
+
+	if err := verifySlackSignature(r.Header, body, signingSecret, time.Now()); err != nil {
+		return interaction, err
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return interaction, fmt.Errorf("parse slack interaction form: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+		return interaction, fmt.Errorf("deserialize slack interaction payload: %v", err)
+	}
+
+	responseUrl, err := url.Parse(interaction.ResponseUrl)
+	if err != nil || responseUrl.Scheme != "https" || responseUrl.Host != slackResponseHost {
+		return interaction, fmt.Errorf("response url of slack interaction is not on %s", slackResponseHost)
+	}
+
+	return interaction, nil
+}
+
+// verifySlackSignature checks X-Slack-Signature header which is HMAC-SHA256 of the version, timestamp and body
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func verifySlackSignature(header http.Header, body []byte, signingSecret string, now time.Time) error {
+	if len(signingSecret) == 0 {
+		return ErrInvalidSlackSignature
+	}
+
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSlackSignature, timestamp)
+	}
+	if age := now.Sub(time.Unix(sentAt, 0)); age > slackSignatureMaxAge || age < -slackSignatureMaxAge {
+		return fmt.Errorf("%w: timestamp is too far from now", ErrInvalidSlackSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrInvalidSlackSignature
+	}
+
+	return nil
+}
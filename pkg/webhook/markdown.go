@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// emojiShortcodes replaces emoji shortcodes used in templates for chats that don't support them
+var emojiShortcodes = strings.NewReplacer(
+	":exclamation:", "❗",
+	":warning:", "⚠️",
+	":white_check_mark:", "✅",
+)
+
+var (
+	markdownLinkRegexp   = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	markdownBoldRegexp   = regexp.MustCompile(`(^|\W)\*([^*\n]+)\*(\W|$)`)
+	markdownItalicRegexp = regexp.MustCompile(`(^|\W)_([^_\n]+)_(\W|$)`)
+	markdownStrikeRegexp = regexp.MustCompile(`~~([^~\n]+)~~`)
+)
+
+// markdownToHTML converts the markdown subset used in templates (links, bold and italic) to HTML
+func markdownToHTML(text string) string {
+	buf := new(strings.Builder)
+	last := 0
+	for _, loc := range markdownLinkRegexp.FindAllStringSubmatchIndex(text, -1) {
+		buf.WriteString(markdownEmphasisToHTML(text[last:loc[0]]))
+		linkText := markdownEmphasisToHTML(text[loc[2]:loc[3]])
+		linkUrl := html.EscapeString(text[loc[4]:loc[5]])
+		buf.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, linkUrl, linkText))
+		last = loc[1]
+	}
+	buf.WriteString(markdownEmphasisToHTML(text[last:]))
+	return buf.String()
+}
+
+func markdownEmphasisToHTML(text string) string {
+	text = html.EscapeString(text)
+	text = markdownBoldRegexp.ReplaceAllString(text, "$1<b>$2</b>$3")
+	return markdownItalicRegexp.ReplaceAllString(text, "$1<i>$2</i>$3")
+}
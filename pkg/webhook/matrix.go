@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+const (
+	matrixSendAttempts = 3
+	matrixRetryDelay   = 2 * time.Second
+)
+
+type MatrixConfig struct {
+	HomeserverUrl string
+	AccessToken   string
+	RoomId        string
+}
+
+type Matrix struct {
+	config MatrixConfig
+	log.Loggable
+}
+
+func NewMatrix(config MatrixConfig) *Matrix {
+	return &Matrix{
+		config: config,
+	}
+}
+
+type MatrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+func (m *Matrix) Send(text string) error {
+	text = emojiShortcodes.Replace(text)
+	return m.SendMessage(MatrixMessage{
+		MsgType:       "m.text",
+		Body:          text,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: strings.ReplaceAll(markdownToHTML(text), "\n", "<br>"),
+	})
+}
+
+// SendMessage sends m.room.message event retrying failed attempts with the same transaction id
+// so the homeserver doesn't duplicate the event if the previous attempt has actually succeeded
+func (m *Matrix) SendMessage(message MatrixMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("serialize message to json: %v", err)
+	}
+
+	txnId, err := newTxnId()
+	if err != nil {
+		return fmt.Errorf("generate transaction id: %v", err)
+	}
+
+	sendUrl := fmt.Sprintf(
+		"%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(m.config.HomeserverUrl, "/"),
+		url.PathEscape(m.config.RoomId),
+		txnId,
+	)
+	headers := map[string]string{
+		"Authorization": "Bearer " + m.config.AccessToken,
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = doRequest(http.MethodPut, sendUrl, data, headers, nil)
+		if err == nil || attempt == matrixSendAttempts || !IsRetryable(err) {
+			break
+		}
+		m.Log().Warnf("Failed to send message to room %s, attempt %d: %v", m.config.RoomId, attempt, err)
+		time.Sleep(matrixRetryDelay * time.Duration(attempt))
+	}
+
+	if err != nil {
+		return fmt.Errorf("send message to room %s: %w", m.config.RoomId, err)
+	}
+	return nil
+}
+
+func newTxnId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
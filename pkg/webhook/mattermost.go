@@ -1,16 +1,12 @@
 package webhook
 
 import (
-	"bytes"
-	"crypto/tls"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-
 	"gitlab-code-review-notifier/pkg/log"
 )
 
+// mattermostMaxPostLen is a mattermost limit of post message length
+const mattermostMaxPostLen = 16383
+
 type MattermostConfig struct {
 	WebhookUrl   string
 	Channel      string
@@ -38,52 +34,52 @@ type MattermostMessage struct {
 }
 
 type MattermostAttachment struct {
-	Title     string `json:"title"`
-	TitleLink string `json:"title_link"`
-	Pretext   string `json:"pretext"`
-	Text      string `json:"text"`
-	Color     string `json:"color"`
+	Title     string             `json:"title"`
+	TitleLink string             `json:"title_link"`
+	Pretext   string             `json:"pretext"`
+	Text      string             `json:"text"`
+	Color     string             `json:"color"`
+	Actions   []MattermostAction `json:"actions,omitempty"`
 }
 
 func (m *Mattermost) Send(text string) error {
+	return m.SendColored(text, "")
+}
+
+func (m *Mattermost) SendColored(text string, color string) error {
+	if len(color) == 0 {
+		color = m.config.DefaultColor
+	}
 	return m.SendMessage(MattermostMessage{
 		Channel:  m.config.Channel,
 		Username: m.config.Username,
 		IconUrl:  m.config.IconUrl,
 		Attachments: []MattermostAttachment{
 			{
-				Color: m.config.DefaultColor,
+				Color: color,
 				Text:  text,
 			},
 		},
 	})
 }
 
-func (m *Mattermost) SendMessage(message MattermostMessage) error {
-	data, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("serialize message %s to json: %v", message, err)
+func (m *Mattermost) SendInteractive(card Card, buttons []Button) error {
+	color := card.Color
+	if len(color) == 0 {
+		color = m.config.DefaultColor
 	}
+	return m.SendMessage(MattermostMessage{
+		Channel:     m.config.Channel,
+		Username:    m.config.Username,
+		IconUrl:     m.config.IconUrl,
+		Attachments: []MattermostAttachment{makeMattermostInteractiveAttachment(card.Text, color, "", buttons)},
+	})
+}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
-	}
-
-	client := &http.Client{Transport: transport}
-
-	resp, err := client.Post(m.config.WebhookUrl, "application/json", bytes.NewBuffer(data))
-	if err != nil {
-		return fmt.Errorf("do request: %v", err)
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("status code is %d body '%s'", resp.StatusCode, body[:500])
-	}
+func (m *Mattermost) SendMessage(message MattermostMessage) error {
+	return postJSON(m.config.WebhookUrl, message)
+}
 
-	return nil
+func (m *Mattermost) MaxTextLength() int {
+	return mattermostMaxPostLen
 }
@@ -0,0 +1,228 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+type MattermostBotConfig struct {
+	ServerUrl    string
+	Token        string
+	ChannelId    string
+	DefaultColor string
+}
+
+// MattermostBot sends messages through mattermost REST API with a bot access token
+type MattermostBot struct {
+	config MattermostBotConfig
+	// botUserId is the id of the bot user itself, it is needed to open direct channels
+	botUserId string
+	mu        sync.Mutex
+	log.Loggable
+}
+
+func NewMattermostBot(config MattermostBotConfig) *MattermostBot {
+	return &MattermostBot{
+		config: config,
+	}
+}
+
+type MattermostPost struct {
+	Id        string                 `json:"id,omitempty"`
+	ChannelId string                 `json:"channel_id"`
+	RootId    string                 `json:"root_id,omitempty"`
+	Message   string                 `json:"message"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
+// MattermostPostPatch contains fields of the post to update
+type MattermostPostPatch struct {
+	Props map[string]interface{} `json:"props,omitempty"`
+}
+
+type mattermostUser struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type mattermostChannel struct {
+	Id string `json:"id"`
+}
+
+func (m *MattermostBot) Send(text string) error {
+	return m.SendColored(text, "")
+}
+
+func (m *MattermostBot) SendColored(text string, color string) error {
+	if len(m.config.ChannelId) == 0 {
+		return fmt.Errorf("channel id is not set")
+	}
+	_, err := m.CreatePost(m.makePost(m.config.ChannelId, text, color))
+	return err
+}
+
+func (m *MattermostBot) SendInteractive(card Card, buttons []Button) error {
+	if len(m.config.ChannelId) == 0 {
+		return fmt.Errorf("channel id is not set")
+	}
+	color := card.Color
+	if len(color) == 0 {
+		color = m.config.DefaultColor
+	}
+	_, err := m.CreatePost(MattermostPost{
+		ChannelId: m.config.ChannelId,
+		Props: map[string]interface{}{
+			"attachments": []MattermostAttachment{makeMattermostInteractiveAttachment(card.Text, color, "", buttons)},
+		},
+	})
+	return err
+}
+
+func (m *MattermostBot) FindUser(username string, email string) (string, error) {
+	var user mattermostUser
+
+	err := m.api(http.MethodGet, "/users/username/"+url.PathEscape(username), nil, &user)
+	if isNotFound(err) && len(email) > 0 {
+		err = m.api(http.MethodGet, "/users/email/"+url.PathEscape(email), nil, &user)
+	}
+	if isNotFound(err) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get user %s: %v", username, err)
+	}
+
+	return user.Id, nil
+}
+
+// GetUsername returns the username of the user with the id
+func (m *MattermostBot) GetUsername(userId string) (string, error) {
+	var user mattermostUser
+	if err := m.api(http.MethodGet, "/users/"+url.PathEscape(userId), nil, &user); err != nil {
+		return "", fmt.Errorf("get user %s: %v", userId, err)
+	}
+	return user.Username, nil
+}
+
+func (m *MattermostBot) SendDirect(userId string, text string) error {
+	channelId, err := m.directChannel(userId)
+	if err != nil {
+		return err
+	}
+	_, err = m.CreatePost(m.makePost(channelId, text, ""))
+	return err
+}
+
+func (m *MattermostBot) Post(text string, color string) (string, error) {
+	if len(m.config.ChannelId) == 0 {
+		return "", fmt.Errorf("channel id is not set")
+	}
+	post, err := m.CreatePost(m.makePost(m.config.ChannelId, text, color))
+	if err != nil {
+		return "", err
+	}
+	return post.Id, nil
+}
+
+func (m *MattermostBot) Reply(rootId string, text string, color string) (string, error) {
+	if len(m.config.ChannelId) == 0 {
+		return "", fmt.Errorf("channel id is not set")
+	}
+	post := m.makePost(m.config.ChannelId, text, color)
+	post.RootId = rootId
+	created, err := m.CreatePost(post)
+	if err != nil {
+		return "", err
+	}
+	return created.Id, nil
+}
+
+func (m *MattermostBot) Update(postId string, text string, color string) error {
+	return m.PatchPost(postId, MattermostPostPatch{Props: m.makePost("", text, color).Props})
+}
+
+func (m *MattermostBot) PatchPost(postId string, patch MattermostPostPatch) error {
+	if err := m.api(http.MethodPut, "/posts/"+url.PathEscape(postId)+"/patch", patch, nil); err != nil {
+		return fmt.Errorf("patch post %s: %w", postId, err)
+	}
+	return nil
+}
+
+func (m *MattermostBot) MaxTextLength() int {
+	return mattermostMaxPostLen
+}
+
+func (m *MattermostBot) CreatePost(post MattermostPost) (*MattermostPost, error) {
+	var created MattermostPost
+	if err := m.api(http.MethodPost, "/posts", post, &created); err != nil {
+		return nil, fmt.Errorf("create post in channel %s: %w", post.ChannelId, err)
+	}
+	return &created, nil
+}
+
+// makePost makes a post highlighted with the color or with the default one if it is empty
+func (m *MattermostBot) makePost(channelId string, text string, color string) MattermostPost {
+	if len(color) == 0 {
+		color = m.config.DefaultColor
+	}
+	return MattermostPost{
+		ChannelId: channelId,
+		Props: map[string]interface{}{
+			"attachments": []MattermostAttachment{
+				{
+					Color: color,
+					Text:  text,
+				},
+			},
+		},
+	}
+}
+
+func (m *MattermostBot) directChannel(userId string) (string, error) {
+	botUserId, err := m.getBotUserId()
+	if err != nil {
+		return "", err
+	}
+
+	var channel mattermostChannel
+	if err := m.api(http.MethodPost, "/channels/direct", []string{botUserId, userId}, &channel); err != nil {
+		return "", fmt.Errorf("create direct channel with user %s: %w", userId, err)
+	}
+
+	return channel.Id, nil
+}
+
+func (m *MattermostBot) getBotUserId() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.botUserId) > 0 {
+		return m.botUserId, nil
+	}
+
+	var user mattermostUser
+	if err := m.api(http.MethodGet, "/users/me", nil, &user); err != nil {
+		return "", fmt.Errorf("get bot user: %v", err)
+	}
+
+	m.botUserId = user.Id
+	return m.botUserId, nil
+}
+
+func (m *MattermostBot) api(method string, path string, payload interface{}, result interface{}) error {
+	apiUrl := strings.TrimSuffix(m.config.ServerUrl, "/") + "/api/v4" + path
+	headers := map[string]string{
+		"Authorization": "Bearer " + m.config.Token,
+	}
+	return doJSON(method, apiUrl, payload, headers, result)
+}
+
+func isNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
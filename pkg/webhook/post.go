@@ -0,0 +1,23 @@
+package webhook
+
+// PostWebhook is implemented by webhooks that send messages with a bot and so know ids of the sent posts
+type PostWebhook interface {
+	Webhook
+	// Post sends the message highlighted with the color, the default one if it is empty,
+	// to the channel returning the id of the created post
+	Post(text string, color string) (string, error)
+}
+
+// EditableWebhook is implemented by webhooks able to update posts they have sent
+type EditableWebhook interface {
+	PostWebhook
+	// Update replaces the text and the color of the post with the id
+	Update(postId string, text string, color string) error
+}
+
+// ThreadWebhook is implemented by webhooks able to reply in threads of posts they have sent
+type ThreadWebhook interface {
+	PostWebhook
+	// Reply sends the message as a reply to the root post with the id returning the id of the reply
+	Reply(rootId string, text string, color string) (string, error)
+}
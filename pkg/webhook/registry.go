@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Settings are webhook settings of a particular client, meaning of each field depends on the webhook type
+type Settings struct {
+	Url          string
+	Token        string
+	Channel      string
+	Secret       string
+	DefaultColor string
+}
+
+type Constructor func(settings Settings) (Webhook, error)
+
+// Registry makes webhooks by their type
+type Registry struct {
+	constructors map[string]Constructor
+}
+
+func NewRegistry() *Registry {
+	return &Registry{constructors: make(map[string]Constructor)}
+}
+
+// NewDefaultRegistry makes a registry with all built-in webhook types
+func NewDefaultRegistry(emailConfig EmailConfig) *Registry {
+	r := NewRegistry()
+	r.Register(TypeMattermost, func(settings Settings) (Webhook, error) {
+		return NewMattermost(MattermostConfig{
+			WebhookUrl:   settings.Url,
+			Channel:      settings.Channel,
+			DefaultColor: settings.DefaultColor,
+		}), nil
+	})
+	r.Register(TypeSlack, func(settings Settings) (Webhook, error) {
+		return NewSlack(SlackConfig{
+			WebhookUrl:   settings.Url,
+			Channel:      settings.Channel,
+			DefaultColor: settings.DefaultColor,
+		}), nil
+	})
+	r.Register(TypeTeams, func(settings Settings) (Webhook, error) {
+		return NewTeams(TeamsConfig{
+			WebhookUrl:   settings.Url,
+			DefaultColor: settings.DefaultColor,
+		}), nil
+	})
+	r.Register(TypeTelegram, func(settings Settings) (Webhook, error) {
+		if len(settings.Token) == 0 || len(settings.Channel) == 0 {
+			return nil, fmt.Errorf("telegram requires bot token and chat id")
+		}
+		return NewTelegram(TelegramConfig{
+			ApiUrl:   settings.Url,
+			BotToken: settings.Token,
+			ChatId:   settings.Channel,
+		}), nil
+	})
+	r.Register(TypeEmail, func(settings Settings) (Webhook, error) {
+		if len(emailConfig.Host) == 0 {
+			return nil, fmt.Errorf("smtp server is not configured")
+		}
+		config := emailConfig
+		config.Recipients = splitList(settings.Channel)
+		return NewEmail(config), nil
+	})
+	r.Register(TypeGeneric, func(settings Settings) (Webhook, error) {
+		return NewGeneric(GenericConfig{
+			WebhookUrl: settings.Url,
+			Secret:     settings.Secret,
+		}), nil
+	})
+	r.Register(TypeDiscord, func(settings Settings) (Webhook, error) {
+		return NewDiscord(DiscordConfig{
+			WebhookUrl:   settings.Url,
+			DefaultColor: settings.DefaultColor,
+		}), nil
+	})
+	r.Register(TypeGoogleChat, func(settings Settings) (Webhook, error) {
+		return NewGoogleChat(GoogleChatConfig{
+			WebhookUrl: settings.Url,
+		}), nil
+	})
+	r.Register(TypeRocketChat, func(settings Settings) (Webhook, error) {
+		return NewRocketChat(RocketChatConfig{
+			WebhookUrl:   settings.Url,
+			Channel:      settings.Channel,
+			DefaultColor: settings.DefaultColor,
+		}), nil
+	})
+	r.Register(TypeMatrix, func(settings Settings) (Webhook, error) {
+		if len(settings.Url) == 0 || len(settings.Token) == 0 || len(settings.Channel) == 0 {
+			return nil, fmt.Errorf("matrix requires homeserver url, access token and room id")
+		}
+		return NewMatrix(MatrixConfig{
+			HomeserverUrl: settings.Url,
+			AccessToken:   settings.Token,
+			RoomId:        settings.Channel,
+		}), nil
+	})
+	r.Register(TypeMattermostBot, func(settings Settings) (Webhook, error) {
+		if len(settings.Url) == 0 || len(settings.Token) == 0 {
+			return nil, fmt.Errorf("mattermost bot requires server url and bot token")
+		}
+		return NewMattermostBot(MattermostBotConfig{
+			ServerUrl:    settings.Url,
+			Token:        settings.Token,
+			ChannelId:    settings.Channel,
+			DefaultColor: settings.DefaultColor,
+		}), nil
+	})
+	r.Register(TypeSlackBot, func(settings Settings) (Webhook, error) {
+		if len(settings.Token) == 0 {
+			return nil, fmt.Errorf("slack bot requires bot token")
+		}
+		return NewSlackBot(SlackBotConfig{
+			ApiUrl:       settings.Url,
+			Token:        settings.Token,
+			Channel:      settings.Channel,
+			DefaultColor: settings.DefaultColor,
+		}), nil
+	})
+	return r
+}
+
+func (r *Registry) Register(webhookType string, constructor Constructor) {
+	r.constructors[webhookType] = constructor
+}
+
+// Make makes a webhook of the type, mattermost is used if type is empty
+func (r *Registry) Make(webhookType string, settings Settings) (Webhook, error) {
+	if len(webhookType) == 0 {
+		webhookType = TypeMattermost
+	}
+	constructor, ok := r.constructors[webhookType]
+	if !ok {
+		return nil, fmt.Errorf("unknown webhook type %s", webhookType)
+	}
+	return constructor(settings)
+}
+
+func splitList(list string) []string {
+	res := make([]string, 0)
+	for _, item := range regexp.MustCompile(`[ ;,]`).Split(list, -1) {
+		if len(item) > 0 {
+			res = append(res, item)
+		}
+	}
+	return res
+}
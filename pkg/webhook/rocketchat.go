@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+// rocketChatMaxMessageLen is a default rocket.chat limit of message length
+const rocketChatMaxMessageLen = 5000
+
+type RocketChatConfig struct {
+	WebhookUrl   string
+	Channel      string
+	Alias        string
+	Avatar       string
+	DefaultColor string
+}
+
+type RocketChat struct {
+	config RocketChatConfig
+	log.Loggable
+}
+
+func NewRocketChat(config RocketChatConfig) *RocketChat {
+	return &RocketChat{
+		config: config,
+	}
+}
+
+type RocketChatMessage struct {
+	Channel     string                 `json:"channel,omitempty"`
+	Alias       string                 `json:"alias,omitempty"`
+	Avatar      string                 `json:"avatar,omitempty"`
+	Text        string                 `json:"text,omitempty"`
+	Attachments []RocketChatAttachment `json:"attachments"`
+}
+
+type RocketChatAttachment struct {
+	Title     string            `json:"title,omitempty"`
+	TitleLink string            `json:"title_link,omitempty"`
+	Text      string            `json:"text"`
+	Color     string            `json:"color,omitempty"`
+	Fields    []RocketChatField `json:"fields,omitempty"`
+}
+
+type RocketChatField struct {
+	Short bool   `json:"short"`
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+func (r *RocketChat) Send(text string) error {
+	return r.SendCard(Card{Text: text})
+}
+
+func (r *RocketChat) SendCard(card Card) error {
+	color := card.Color
+	if len(color) == 0 {
+		color = r.config.DefaultColor
+	}
+
+	fields := make([]RocketChatField, 0, len(card.Facts))
+	for _, fact := range card.Facts {
+		fields = append(fields, RocketChatField{Short: true, Title: fact.Name, Value: fact.Value})
+	}
+
+	return r.SendMessage(RocketChatMessage{
+		Channel: r.config.Channel,
+		Alias:   r.config.Alias,
+		Avatar:  r.config.Avatar,
+		Attachments: []RocketChatAttachment{
+			{
+				Title:     card.Title,
+				TitleLink: card.TitleLink,
+				Text:      card.Text,
+				Color:     color,
+				Fields:    fields,
+			},
+		},
+	})
+}
+
+func (r *RocketChat) SendMessage(message RocketChatMessage) error {
+	return postJSON(r.config.WebhookUrl, message)
+}
+
+func (r *RocketChat) MaxTextLength() int {
+	return rocketChatMaxMessageLen
+}
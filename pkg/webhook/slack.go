@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	"strings"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+// slackMaxTextLen is a slack limit of text length in a block
+const slackMaxTextLen = 3000
+
+// slackMaxHeaderLen is a slack limit of text length in a header block
+const slackMaxHeaderLen = 150
+
+var slackMentions = strings.NewReplacer(
+	"@all", "<!channel>",
+	"@channel", "<!channel>",
+	"@here", "<!here>",
+)
+
+type SlackConfig struct {
+	WebhookUrl   string
+	Channel      string
+	Username     string
+	IconUrl      string
+	DefaultColor string
+}
+
+type Slack struct {
+	config SlackConfig
+	log.Loggable
+}
+
+func NewSlack(config SlackConfig) *Slack {
+	return &Slack{
+		config: config,
+	}
+}
+
+type SlackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconUrl     string            `json:"icon_url,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []SlackAttachment `json:"attachments,omitempty"`
+	// Ts is the timestamp of the message to update
+	Ts string `json:"ts,omitempty"`
+	// ThreadTs is the timestamp of the message to reply to
+	ThreadTs string `json:"thread_ts,omitempty"`
+}
+
+type SlackAttachment struct {
+	Color  string       `json:"color,omitempty"`
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+type SlackBlock struct {
+	Type    string     `json:"type"`
+	BlockId string     `json:"block_id,omitempty"`
+	Text    *SlackText `json:"text,omitempty"`
+	// either SlackText elements of a context block or SlackButton elements of an actions block
+	Elements []interface{} `json:"elements,omitempty"`
+}
+
+type SlackButton struct {
+	Type     string     `json:"type"`
+	Text     *SlackText `json:"text,omitempty"`
+	Url      string     `json:"url,omitempty"`
+	ActionId string     `json:"action_id,omitempty"`
+	Value    string     `json:"value,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (s *Slack) Send(text string) error {
+	return s.SendCard(Card{Text: text})
+}
+
+func (s *Slack) SendCard(card Card) error {
+	return s.SendInteractive(card, nil)
+}
+
+func (s *Slack) SendInteractive(card Card, buttons []Button) error {
+	text := slackMarkdown(card.Text)
+
+	color := card.Color
+	if len(color) == 0 {
+		color = s.config.DefaultColor
+	}
+
+	return s.SendMessage(SlackMessage{
+		Channel:  s.config.Channel,
+		Username: s.config.Username,
+		IconUrl:  s.config.IconUrl,
+		Text:     text,
+		Attachments: []SlackAttachment{
+			{
+				Color:  color,
+				Blocks: makeSlackBlocks(card.Title, text, card.TitleLink, buttons),
+			},
+		},
+	})
+}
+
+func (s *Slack) SendMessage(message SlackMessage) error {
+	return postJSON(s.config.WebhookUrl, message)
+}
+
+func (s *Slack) MaxTextLength() int {
+	return slackMaxTextLen
+}
+
+// makeSlackBlocks puts the title of the card into a header block if it is set, the headline of the message into a section block,
+// the rest of lines into a context block and adds a button leading to the merge request along with interactive ones
+func makeSlackBlocks(title string, text string, link string, buttons []Button) []SlackBlock {
+	lines := strings.SplitN(strings.TrimSpace(text), "\n", 2)
+
+	blocks := make([]SlackBlock, 0, 4)
+	if len(title) > 0 {
+		if runes := []rune(title); len(runes) > slackMaxHeaderLen {
+			title = string(runes[:slackMaxHeaderLen-1]) + "…"
+		}
+		blocks = append(blocks, SlackBlock{
+			Type: "header",
+			Text: &SlackText{Type: "plain_text", Text: title},
+		})
+	}
+	blocks = append(blocks, SlackBlock{
+		Type: "section",
+		Text: &SlackText{Type: "mrkdwn", Text: lines[0]},
+	})
+
+	if len(lines) > 1 && len(strings.TrimSpace(lines[1])) > 0 {
+		blocks = append(blocks, SlackBlock{
+			Type: "context",
+			Elements: []interface{}{
+				SlackText{Type: "mrkdwn", Text: lines[1]},
+			},
+		})
+	}
+
+	elements := make([]interface{}, 0, len(buttons)+1)
+	if len(link) > 0 {
+		elements = append(elements, SlackButton{
+			Type: "button",
+			Text: &SlackText{Type: "plain_text", Text: "Open merge request"},
+			Url:  link,
+		})
+	}
+	for _, button := range buttons {
+		elements = append(elements, SlackButton{
+			Type:     "button",
+			Text:     &SlackText{Type: "plain_text", Text: button.Label},
+			ActionId: button.Action,
+			Value:    button.Value,
+		})
+	}
+
+	if len(elements) > 0 {
+		blocks = append(blocks, SlackBlock{
+			Type:     "actions",
+			Elements: elements,
+		})
+	}
+
+	return blocks
+}
+
+// slackMarkdown converts markdown used in templates to slack mrkdwn
+func slackMarkdown(text string) string {
+	text = markdownLinkRegexp.ReplaceAllString(text, "<$2|$1>")
+	text = markdownStrikeRegexp.ReplaceAllString(text, "~$1~")
+	return slackMentions.Replace(text)
+}
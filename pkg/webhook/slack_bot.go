@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+const DefaultSlackApiUrl = "https://slack.com/api"
+
+type SlackBotConfig struct {
+	// ApiUrl is the base url of the web api, DefaultSlackApiUrl if empty
+	ApiUrl       string
+	Token        string
+	Channel      string
+	DefaultColor string
+}
+
+// SlackBot sends messages through slack web api with a bot token
+type SlackBot struct {
+	config SlackBotConfig
+	log.Loggable
+}
+
+func NewSlackBot(config SlackBotConfig) *SlackBot {
+	if len(config.ApiUrl) == 0 {
+		config.ApiUrl = DefaultSlackApiUrl
+	}
+	return &SlackBot{
+		config: config,
+	}
+}
+
+// slackResponse is a common part of web api responses which report errors with 200 status code
+type slackResponse struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type slackUserResponse struct {
+	slackResponse
+	User struct {
+		Id string `json:"id"`
+	} `json:"user"`
+}
+
+type SlackPostMessageResponse struct {
+	slackResponse
+	Channel string `json:"channel"`
+	Ts      string `json:"ts"`
+}
+
+func (s *SlackBot) Send(text string) error {
+	return s.SendColored(text, "")
+}
+
+func (s *SlackBot) SendColored(text string, color string) error {
+	if len(s.config.Channel) == 0 {
+		return fmt.Errorf("channel is not set")
+	}
+	_, err := s.PostMessage(s.makeMessage(s.config.Channel, text, color))
+	return err
+}
+
+func (s *SlackBot) SendInteractive(card Card, buttons []Button) error {
+	if len(s.config.Channel) == 0 {
+		return fmt.Errorf("channel is not set")
+	}
+	message := s.makeMessage(s.config.Channel, card.Text, card.Color)
+	message.Attachments[0].Blocks = makeSlackBlocks(card.Title, message.Text, card.TitleLink, buttons)
+	_, err := s.PostMessage(message)
+	return err
+}
+
+// FindUser finds the user by email only as slack doesn't allow to look users up by username
+func (s *SlackBot) FindUser(username string, email string) (string, error) {
+	if len(email) == 0 {
+		return "", ErrUserNotFound
+	}
+
+	var resp slackUserResponse
+	if err := s.api(http.MethodGet, "/users.lookupByEmail?email="+url.QueryEscape(email), nil, &resp); err != nil {
+		return "", fmt.Errorf("lookup user %s by email: %v", username, err)
+	}
+	if resp.Error == "users_not_found" {
+		return "", ErrUserNotFound
+	}
+	if !resp.Ok {
+		return "", fmt.Errorf("lookup user %s by email: %s", username, resp.Error)
+	}
+
+	return resp.User.Id, nil
+}
+
+// SendDirect sends the message to the app home of the user
+func (s *SlackBot) SendDirect(userId string, text string) error {
+	_, err := s.PostMessage(s.makeMessage(userId, text, ""))
+	return err
+}
+
+// Post returns the channel id and the timestamp of the message separated by a colon
+// as both of them are needed to update the message
+func (s *SlackBot) Post(text string, color string) (string, error) {
+	if len(s.config.Channel) == 0 {
+		return "", fmt.Errorf("channel is not set")
+	}
+	resp, err := s.PostMessage(s.makeMessage(s.config.Channel, text, color))
+	if err != nil {
+		return "", err
+	}
+	return resp.Channel + ":" + resp.Ts, nil
+}
+
+func (s *SlackBot) Reply(rootId string, text string, color string) (string, error) {
+	channel, ts, err := parseSlackPostId(rootId)
+	if err != nil {
+		return "", err
+	}
+	message := s.makeMessage(channel, text, color)
+	message.ThreadTs = ts
+	resp, err := s.PostMessage(message)
+	if err != nil {
+		return "", err
+	}
+	return resp.Channel + ":" + resp.Ts, nil
+}
+
+func (s *SlackBot) Update(postId string, text string, color string) error {
+	channel, ts, err := parseSlackPostId(postId)
+	if err != nil {
+		return err
+	}
+	message := s.makeMessage(channel, text, color)
+	message.Ts = ts
+	return s.UpdateMessage(message)
+}
+
+func (s *SlackBot) UpdateMessage(message SlackMessage) error {
+	var resp slackResponse
+	if err := s.api(http.MethodPost, "/chat.update", message, &resp); err != nil {
+		return fmt.Errorf("update message %s in %s: %w", message.Ts, message.Channel, err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("update message %s in %s: %s", message.Ts, message.Channel, resp.Error)
+	}
+	return nil
+}
+
+func (s *SlackBot) MaxTextLength() int {
+	return slackMaxTextLen
+}
+
+func (s *SlackBot) PostMessage(message SlackMessage) (*SlackPostMessageResponse, error) {
+	var resp SlackPostMessageResponse
+	if err := s.api(http.MethodPost, "/chat.postMessage", message, &resp); err != nil {
+		return nil, fmt.Errorf("post message to %s: %w", message.Channel, err)
+	}
+	if !resp.Ok {
+		return nil, fmt.Errorf("post message to %s: %s", message.Channel, resp.Error)
+	}
+	return &resp, nil
+}
+
+// makeMessage makes a message highlighted with the color or with the default one if it is empty
+func (s *SlackBot) makeMessage(channel string, text string, color string) SlackMessage {
+	if len(color) == 0 {
+		color = s.config.DefaultColor
+	}
+	text = slackMarkdown(text)
+	return SlackMessage{
+		Channel: channel,
+		Text:    text,
+		Attachments: []SlackAttachment{
+			{
+				Color:  color,
+				Blocks: makeSlackBlocks("", text, "", nil),
+			},
+		},
+	}
+}
+
+// parseSlackPostId splits the post id returned by Post into the channel id and the message timestamp
+func parseSlackPostId(postId string) (string, string, error) {
+	parts := strings.SplitN(postId, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("malformed post id %s", postId)
+	}
+	return parts[0], parts[1], nil
+}
+
+func (s *SlackBot) api(method string, path string, payload interface{}, result interface{}) error {
+	apiUrl := strings.TrimSuffix(s.config.ApiUrl, "/") + path
+	headers := map[string]string{
+		"Authorization": "Bearer " + s.config.Token,
+	}
+	return doJSON(method, apiUrl, payload, headers, result)
+}
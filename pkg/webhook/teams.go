@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"strings"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+type TeamsConfig struct {
+	WebhookUrl   string
+	DefaultColor string
+}
+
+type Teams struct {
+	config TeamsConfig
+	log.Loggable
+}
+
+func NewTeams(config TeamsConfig) *Teams {
+	return &Teams{
+		config: config,
+	}
+}
+
+// TeamsMessageCard is a legacy actionable message card supported by teams incoming webhooks
+type TeamsMessageCard struct {
+	Type            string         `json:"@type"`
+	Context         string         `json:"@context"`
+	ThemeColor      string         `json:"themeColor,omitempty"`
+	Summary         string         `json:"summary"`
+	Title           string         `json:"title,omitempty"`
+	Sections        []TeamsSection `json:"sections"`
+	PotentialAction []TeamsAction  `json:"potentialAction,omitempty"`
+}
+
+type TeamsSection struct {
+	Text     string      `json:"text,omitempty"`
+	Facts    []TeamsFact `json:"facts,omitempty"`
+	Markdown bool        `json:"markdown"`
+}
+
+type TeamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type TeamsAction struct {
+	Type    string        `json:"@type"`
+	Name    string        `json:"name"`
+	Targets []TeamsTarget `json:"targets"`
+}
+
+type TeamsTarget struct {
+	Os  string `json:"os"`
+	Uri string `json:"uri"`
+}
+
+func (t *Teams) Send(text string) error {
+	return t.SendCard(Card{Text: text})
+}
+
+func (t *Teams) SendCard(card Card) error {
+	text := emojiShortcodes.Replace(card.Text)
+
+	color := card.Color
+	if len(color) == 0 {
+		color = t.config.DefaultColor
+	}
+
+	summary := card.Title
+	if len(summary) == 0 {
+		summary = strings.SplitN(text, "\n", 2)[0]
+	}
+
+	facts := make([]TeamsFact, 0, len(card.Facts))
+	for _, fact := range card.Facts {
+		facts = append(facts, TeamsFact{Name: fact.Name, Value: fact.Value})
+	}
+
+	message := TeamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: strings.TrimPrefix(color, "#"),
+		Summary:    summary,
+		Title:      card.Title,
+		Sections: []TeamsSection{
+			{
+				// teams needs two trailing spaces to break a line in markdown
+				Text:     strings.ReplaceAll(text, "\n", "  \n"),
+				Facts:    facts,
+				Markdown: true,
+			},
+		},
+	}
+
+	if len(card.TitleLink) > 0 {
+		message.PotentialAction = []TeamsAction{
+			{
+				Type:    "OpenUri",
+				Name:    "Open in GitLab",
+				Targets: []TeamsTarget{{Os: "default", Uri: card.TitleLink}},
+			},
+		}
+	}
+
+	return t.SendMessage(message)
+}
+
+func (t *Teams) SendMessage(message TeamsMessageCard) error {
+	return postJSON(t.config.WebhookUrl, message)
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab-code-review-notifier/pkg/log"
+)
+
+const DefaultTelegramApiUrl = "https://api.telegram.org"
+
+// telegramMaxMessageLen is a telegram limit of message text length
+const telegramMaxMessageLen = 4096
+
+type TelegramConfig struct {
+	// ApiUrl is the base url of the bot api, DefaultTelegramApiUrl if empty
+	ApiUrl   string
+	BotToken string
+	ChatId   string
+}
+
+type Telegram struct {
+	config TelegramConfig
+	log.Loggable
+}
+
+func NewTelegram(config TelegramConfig) *Telegram {
+	if len(config.ApiUrl) == 0 {
+		config.ApiUrl = DefaultTelegramApiUrl
+	}
+	return &Telegram{
+		config: config,
+	}
+}
+
+type TelegramMessage struct {
+	ChatId                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+func (t *Telegram) Send(text string) error {
+	return t.SendMessage(TelegramMessage{
+		ChatId:                t.config.ChatId,
+		Text:                  markdownToHTML(emojiShortcodes.Replace(text)),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	})
+}
+
+func (t *Telegram) SendMessage(message TelegramMessage) error {
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(t.config.ApiUrl, "/"), t.config.BotToken)
+	if err := postJSON(url, message); err != nil {
+		return fmt.Errorf("send message to chat %s: %w", message.ChatId, err)
+	}
+	return nil
+}
+
+func (t *Telegram) MaxTextLength() int {
+	return telegramMaxMessageLen
+}
@@ -1,5 +1,62 @@
 package webhook
 
+const (
+	TypeMattermost = "mattermost"
+	TypeSlack      = "slack"
+	TypeTeams      = "teams"
+	TypeTelegram   = "telegram"
+	TypeEmail      = "email"
+	TypeGeneric    = "generic"
+	TypeDiscord    = "discord"
+	TypeGoogleChat = "google_chat"
+	TypeRocketChat = "rocketchat"
+	TypeMatrix     = "matrix"
+	// TypeMattermostBot and TypeSlackBot send messages with bot tokens through chat APIs instead of incoming webhooks
+	TypeMattermostBot = "mattermost_bot"
+	TypeSlackBot      = "slack_bot"
+)
+
 type Webhook interface {
 	Send(text string) error
 }
+
+// Card is a notification message along with the merge request it is about
+// so webhooks can render a link to it natively instead of only inline in the text
+type Card struct {
+	Title     string
+	TitleLink string
+	Text      string
+	Color     string
+	Facts     []Fact
+}
+
+// Fact is a named detail of a notification like the merge request age
+type Fact struct {
+	Name  string
+	Value string
+}
+
+// CardWebhook is implemented by webhooks that can render a Card
+type CardWebhook interface {
+	Webhook
+	SendCard(card Card) error
+}
+
+// LimitedWebhook is implemented by webhooks whose platform limits the length of a message text
+type LimitedWebhook interface {
+	Webhook
+	MaxTextLength() int
+}
+
+// UserNameResolver is implemented by webhooks which can look chat users up by their ids
+type UserNameResolver interface {
+	Webhook
+	GetUsername(userId string) (string, error)
+}
+
+// ColorWebhook is implemented by webhooks that can highlight a text message with a color
+type ColorWebhook interface {
+	Webhook
+	// SendColored sends the message highlighted with the color or with the default one if it is empty
+	SendColored(text string, color string) error
+}
@@ -0,0 +1,42 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Run calls fn for each index from 0 to count-1 with at most workers calls at once and waits for them to finish,
+// indexes which are not started yet when the context is done are skipped.
+// Callers keep results in order by storing them at the index
+func Run(ctx context.Context, workers int, count int, fn func(ctx context.Context, i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > count {
+		workers = count
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(ctx, i)
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < count; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+
+	wg.Wait()
+}